@@ -0,0 +1,224 @@
+package gowebdav
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	pathpkg "path"
+	"strings"
+)
+
+// DownloadTar writes the remote directory tree at remoteDir to w as a tar archive,
+// without holding the files on disk. The paths in the archive are relative to
+// remoteDir and the modification times are those reported by the server. Each
+// file is streamed from the server straight into the archive, except that a file
+// whose size the server did not report is first read into memory, because a tar
+// header needs the size.
+//
+// If it fails part-way, the archive is incomplete and its trailer is not written.
+func (c *client) DownloadTar(remoteDir string, w io.Writer) error {
+	tw := tar.NewWriter(w)
+
+	err := c.walkArchive("DownloadTar", remoteDir, func(rel string, info os.FileInfo) error {
+		hdr := &tar.Header{
+			Name:    rel,
+			ModTime: info.ModTime(),
+			Mode:    0644,
+		}
+		if info.IsDir() {
+			hdr.Name += "/"
+			hdr.Typeflag = tar.TypeDir
+			hdr.Mode = 0755
+			return tw.WriteHeader(hdr)
+		}
+
+		stream, size, err := c.sizedStream(pathpkg.Join(remoteDir, rel), info)
+		if err != nil {
+			return err
+		}
+		defer stream.Close()
+
+		hdr.Typeflag = tar.TypeReg
+		hdr.Size = size
+		if err = tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err = io.CopyN(tw, stream, size); err != nil {
+			return fmt.Errorf("%s: %w", rel, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
+
+// DownloadZip writes the remote directory tree at remoteDir to w as a zip archive,
+// without holding the files on disk or in memory. The paths in the archive are
+// relative to remoteDir and the modification times are those reported by the
+// server. The files are compressed with Deflate.
+//
+// If it fails part-way, the archive is incomplete and its central directory is
+// not written.
+func (c *client) DownloadZip(remoteDir string, w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	err := c.walkArchive("DownloadZip", remoteDir, func(rel string, info os.FileInfo) error {
+		hdr := &zip.FileHeader{
+			Name:     rel,
+			Modified: info.ModTime(),
+			Method:   zip.Deflate,
+		}
+		if info.IsDir() {
+			hdr.Name += "/"
+			hdr.Method = zip.Store
+			hdr.SetMode(os.ModeDir | 0755)
+			_, err := zw.CreateHeader(hdr)
+			return err
+		}
+
+		stream, err := c.ReadStream(pathpkg.Join(remoteDir, rel))
+		if err != nil {
+			return err
+		}
+		defer stream.Close()
+
+		hdr.SetMode(0644)
+		fw, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(fw, stream)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// walkArchive walks the tree at remoteDir, calling fn with the slash-separated
+// path relative to remoteDir of each file and collection below it.
+func (c *client) walkArchive(op, remoteDir string, fn func(rel string, info os.FileInfo) error) error {
+	remoteDir = pathpkg.Clean(withLeadingSlash(remoteDir))
+
+	err := c.Walk(remoteDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel := relativePath(remoteDir, p)
+		if rel == "." {
+			if !info.IsDir() {
+				return newPathErrorErr(op, remoteDir, ErrNotDirectory)
+			}
+			return nil
+		}
+		return fn(rel, info)
+	})
+	if err != nil {
+		return withOp(op, remoteDir, err)
+	}
+	return nil
+}
+
+// sizedStream opens a remote file and gets its size, which is that reported by
+// the server, or found by reading the file into memory if it is not known.
+func (c *client) sizedStream(path string, info os.FileInfo) (io.ReadCloser, int64, error) {
+	stream, res, err := c.ReadStreamWithResponse(path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch {
+	case hasSize(info):
+		return stream, info.Size(), nil
+	case res.ContentLength >= 0:
+		return stream, res.ContentLength, nil
+	}
+
+	defer stream.Close()
+	data, err := ioutil.ReadAll(stream)
+	if err != nil {
+		return nil, 0, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), int64(len(data)), nil
+}
+
+// UploadTar expands the tar archive read from r onto the server below remoteDir.
+// Directory entries are created as collections and regular files are uploaded,
+// streaming each straight from the archive. The parent collections of each entry
+// are created before it, whatever order the archive lists them in. Other kinds of
+// entry, such as symbolic links, are skipped. An entry whose path would lie outside
+// remoteDir is an error.
+//
+// It stops at the first failure, so the tree on the server may be incomplete.
+func (c *client) UploadTar(remoteDir string, r io.Reader) error {
+	remoteDir = pathpkg.Clean(withLeadingSlash(remoteDir))
+	created := map[string]bool{"/": true}
+
+	mkdirAll := func(dir string) error {
+		if created[dir] {
+			return nil
+		}
+		if err := c.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		for ; dir != "/"; dir = pathpkg.Dir(dir) {
+			created[dir] = true
+		}
+		return nil
+	}
+
+	if err := mkdirAll(remoteDir); err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return newPathErrorErr("UploadTar", remoteDir, err)
+		}
+
+		if strings.HasPrefix(hdr.Name, "/") || strings.Contains("/"+hdr.Name+"/", "/../") {
+			return newPathErrorErr("UploadTar", remoteDir, fmt.Errorf("unsafe path %q in archive", hdr.Name))
+		}
+		target := pathpkg.Join(remoteDir, hdr.Name)
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = mkdirAll(target)
+
+		case tar.TypeReg:
+			if err = mkdirAll(pathpkg.Dir(target)); err == nil {
+				err = c.putSized("UploadTar", target, tr, hdr.Size)
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// putSized uploads size bytes from stream, without creating parent collections.
+func (c *client) putSized(op, path string, stream io.Reader, size int64) error {
+	_, err := c.put(op, path, stream, func(rq *http.Request) {
+		rq.ContentLength = size
+		if size == 0 {
+			rq.Body = http.NoBody
+		}
+	})
+	return err
+}
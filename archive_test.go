@@ -0,0 +1,140 @@
+package gowebdav_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/rickb777/gowebdav"
+	"golang.org/x/net/webdav"
+)
+
+func archiveServer(t *testing.T) (*httptest.Server, gowebdav.Client) {
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+
+	client := gowebdav.NewClient(server.URL)
+	must(t, client.MkdirAll("top/sub/empty", 0755))
+	must(t, client.WriteFile("top/a.txt", []byte("alpha"), 0644))
+	must(t, client.WriteFile("top/sub/b.txt", []byte("beta"), 0644))
+	return server, client
+}
+
+func TestDownloadTar(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server, client := archiveServer(t)
+	defer server.Close()
+
+	buf := &bytes.Buffer{}
+	g.Expect(client.DownloadTar("top", buf)).To(Succeed())
+
+	var entries []string
+	tr := tar.NewReader(buf)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(hdr.ModTime).To(BeTemporally("~", time.Now(), time.Minute))
+		data, _ := io.ReadAll(tr)
+		entries = append(entries, hdr.Name+" "+string(data))
+	}
+
+	g.Expect(entries).To(Equal([]string{"a.txt alpha", "sub/ ", "sub/b.txt beta", "sub/empty/ "}))
+
+	g.Expect(client.DownloadTar("top/a.txt", &bytes.Buffer{})).NotTo(Succeed())
+	g.Expect(client.DownloadTar("missing", &bytes.Buffer{})).NotTo(Succeed())
+}
+
+func TestDownloadZip(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server, client := archiveServer(t)
+	defer server.Close()
+
+	buf := &bytes.Buffer{}
+	g.Expect(client.DownloadZip("/top/", buf)).To(Succeed())
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	var entries []string
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		g.Expect(err).NotTo(HaveOccurred())
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		g.Expect(f.Modified).To(BeTemporally("~", time.Now(), time.Minute))
+		entries = append(entries, f.Name+" "+string(data))
+	}
+
+	g.Expect(entries).To(Equal([]string{"a.txt alpha", "sub/ ", "sub/b.txt beta", "sub/empty/ "}))
+}
+
+func TestUploadTar(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	// the files come before their directories, one of which is not listed at all
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	for _, e := range []struct{ name, data string }{
+		{"./a/b/c.txt", "see"},
+		{"a/b/", ""},
+		{"a/d.txt", ""},
+		{"a/empty/", ""},
+		{"link", ""},
+	} {
+		hdr := &tar.Header{Name: e.name, Mode: 0644, Size: int64(len(e.data)), Typeflag: tar.TypeReg}
+		switch {
+		case e.name == "link":
+			hdr.Typeflag = tar.TypeSymlink
+			hdr.Linkname = "a/d.txt"
+		case e.name[len(e.name)-1] == '/':
+			hdr.Typeflag = tar.TypeDir
+		}
+		must(t, tw.WriteHeader(hdr))
+		_, err := tw.Write([]byte(e.data))
+		must(t, err)
+	}
+	must(t, tw.Close())
+
+	g.Expect(client.UploadTar("dest/x", buf)).To(Succeed())
+
+	bs, err := client.ReadFile("dest/x/a/b/c.txt")
+	g.Expect(string(bs), err).To(Equal("see"))
+	bs, err = client.ReadFile("dest/x/a/d.txt")
+	g.Expect(string(bs), err).To(Equal(""))
+
+	fi, err := client.Stat("dest/x/a/empty")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(fi.IsDir()).To(BeTrue())
+
+	_, err = client.Stat("dest/x/link")
+	g.Expect(err).To(HaveOccurred())
+
+	// entries must not escape the destination
+	buf.Reset()
+	tw = tar.NewWriter(buf)
+	must(t, tw.WriteHeader(&tar.Header{Name: "../escape.txt", Mode: 0644, Typeflag: tar.TypeReg}))
+	must(t, tw.Close())
+
+	err = client.UploadTar("dest/x", buf)
+	g.Expect(err).To(MatchError(ContainSubstring("unsafe path")))
+}
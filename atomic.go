@@ -0,0 +1,58 @@
+package gowebdav
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// WriteFileAtomic writes data to path so that readers never see a partly written
+// file. The data is first uploaded to a temporary sibling, named like
+// path.tmp-1a2b3c4d, which is then moved into place with Overwrite: T. The content
+// type ct is optional. If either step fails, the temporary file is removed.
+//
+// The move is only as atomic as the server makes it. Some servers implement MOVE
+// as a copy followed by a delete, so a reader may briefly see a partial copy; but
+// it never sees a partial upload. If such a server copies the file but then fails,
+// the temporary file is still removed.
+func (c *client) WriteFileAtomic(path string, data []byte, ct string) error {
+	path = withLeadingSlash(path)
+
+	suffix, err := tempSuffix()
+	if err != nil {
+		return newPathErrorErr("WriteFileAtomic", path, err)
+	}
+	temp := path + ".tmp-" + suffix
+
+	err = c.WriteFile(temp, data, 0, func(rq *http.Request) {
+		if ct != "" {
+			rq.Header.Set("Content-Type", ct)
+		}
+	})
+	if err == nil {
+		err = c.copymove("WriteFileAtomic", MethodMove, temp, path, "infinity", true)
+		if err == nil {
+			return nil
+		}
+	}
+
+	c.removeTemp(temp)
+	return err
+}
+
+// removeTemp deletes a temporary file, ignoring any failure.
+func (c *client) removeTemp(temp string) {
+	res, err := c.request("WriteFileAtomic", http.MethodDelete, temp, nil, nil)
+	if err == nil {
+		_ = res.Body.Close()
+	}
+	c.InvalidateCache(temp)
+}
+
+func tempSuffix() (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
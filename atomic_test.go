@@ -0,0 +1,60 @@
+package gowebdav_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/rickb777/gowebdav"
+	"golang.org/x/net/webdav"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	handler := &webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	}
+	var methods []string
+	refuseMove := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		if r.Method == "MOVE" && refuseMove {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+	must(t, client.Mkdir("dir", 0755))
+	must(t, client.WriteFile("dir/a.txt", []byte("old"), 0644))
+
+	methods = nil
+	g.Expect(client.WriteFileAtomic("dir/a.txt", []byte("new"), "text/plain")).To(Succeed())
+	g.Expect(methods).To(Equal([]string{"PUT", "MOVE"}))
+
+	bs, err := client.ReadFile("dir/a.txt")
+	g.Expect(string(bs), err).To(Equal("new"))
+
+	files, err := client.ReadDir("dir")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(files).To(HaveLen(1))
+
+	// the temporary file is removed when the move fails
+	refuseMove = true
+	err = client.WriteFileAtomic("dir/a.txt", []byte("newer"), "")
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.(*gowebdav.StatusError).StatusCode).To(Equal(http.StatusForbidden))
+
+	files, err = client.ReadDir("dir")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(files).To(HaveLen(1))
+	g.Expect(files[0].Name()).To(Equal("a.txt"))
+
+	bs, err = client.ReadFile("dir/a.txt")
+	g.Expect(string(bs), err).To(Equal("new"))
+}
@@ -7,11 +7,19 @@ type Authenticator interface {
 	Type() string
 	User() string
 	Password() string
-	Authorize(*http.Request)
+
+	// Authorize adds credentials to the request. It returns an error if
+	// the credentials could not be obtained, e.g. when a token cannot be
+	// refreshed, so that the request is not sent unauthenticated.
+	Authorize(*http.Request) error
 }
 
 var Anonymous Authenticator = &noAuth{}
 
+// Deferred holds credentials without sending them. The first request that gets
+// a 401 challenge chooses Digest or Basic authentication, and the client keeps
+// using the chosen authenticator, so later requests send the credentials
+// preemptively without another 401 round trip.
 func Deferred(user string, pw string) Authenticator {
 	return &noAuth{
 		user: user,
@@ -41,4 +49,6 @@ func (n *noAuth) Password() string {
 }
 
 // Authorize the current request
-func (n *noAuth) Authorize(_ *http.Request) {}
+func (n *noAuth) Authorize(_ *http.Request) error {
+	return nil
+}
@@ -33,8 +33,9 @@ func (b *basicAuth) Password() string {
 }
 
 // Authorize the current request.
-func (b *basicAuth) Authorize(req *http.Request) {
+func (b *basicAuth) Authorize(req *http.Request) error {
 	a := b.user + ":" + b.pw
 	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(a))
 	req.Header.Set("Authorization", auth)
+	return nil
 }
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"net/http"
+)
+
+// Bearer implements authentication using a fixed bearer token, as used by
+// OAuth2 and OpenID Connect protected servers.
+func Bearer(token string) Authenticator {
+	return &bearerAuth{
+		tokenFn: func() (string, error) {
+			return token, nil
+		},
+	}
+}
+
+// BearerFunc implements authentication using a bearer token obtained from fn
+// just before each request, so that expiring tokens can be renewed.
+func BearerFunc(fn func() (string, error)) Authenticator {
+	return &bearerAuth{
+		tokenFn: fn,
+	}
+}
+
+type bearerAuth struct {
+	tokenFn func() (string, error)
+}
+
+// Type identifies the Bearer authenticator.
+func (b *bearerAuth) Type() string {
+	return "Bearer"
+}
+
+// User is not used by the Bearer authenticator.
+func (b *bearerAuth) User() string {
+	return ""
+}
+
+// Password is not used by the Bearer authenticator.
+func (b *bearerAuth) Password() string {
+	return ""
+}
+
+// Authorize the current request.
+func (b *bearerAuth) Authorize(req *http.Request) error {
+	token, err := b.tokenFn()
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestBearer(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	b := Bearer("abc123")
+	g.Expect(b.Type()).To(Equal("Bearer"))
+
+	rq, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+	g.Expect(b.Authorize(rq)).NotTo(HaveOccurred())
+	g.Expect(rq.Header.Get("Authorization")).To(Equal("Bearer abc123"))
+}
+
+func TestBearerFunc(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	n := 0
+	b := BearerFunc(func() (string, error) {
+		n++
+		return fmt.Sprintf("token%d", n), nil
+	})
+
+	rq1, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+	g.Expect(b.Authorize(rq1)).NotTo(HaveOccurred())
+	g.Expect(rq1.Header.Get("Authorization")).To(Equal("Bearer token1"))
+
+	rq2, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+	g.Expect(b.Authorize(rq2)).NotTo(HaveOccurred())
+	g.Expect(rq2.Header.Get("Authorization")).To(Equal("Bearer token2"))
+}
+
+func TestBearerFunc_error(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	b := BearerFunc(func() (string, error) {
+		return "", errors.New("expired")
+	})
+
+	rq, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+	g.Expect(b.Authorize(rq)).To(MatchError("expired"))
+	g.Expect(rq.Header.Get("Authorization")).To(BeEmpty())
+}
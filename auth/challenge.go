@@ -0,0 +1,113 @@
+package auth
+
+import "strings"
+
+// authChallenge is one of the challenges in a Www-Authenticate header, as defined by
+// https://tools.ietf.org/html/rfc7235#section-4.1. Parameter names are lower case.
+type authChallenge struct {
+	scheme string
+	params map[string]string
+}
+
+// parseChallenges splits a Www-Authenticate header into its challenges. Quoted
+// values may contain commas, spaces and escaped quotes. Malformed parts are skipped.
+func parseChallenges(header string) []authChallenge {
+	var challenges []authChallenge
+	s := header
+
+	for {
+		s = skipSeparators(s)
+		if s == "" {
+			return challenges
+		}
+
+		var name string
+		name, s = readToken(s)
+		if name == "" {
+			// not a token; skip to the next comma
+			if i := strings.IndexByte(s, ','); i >= 0 {
+				s = s[i+1:]
+				continue
+			}
+			return challenges
+		}
+
+		rest := strings.TrimLeft(s, " \t")
+		if strings.HasPrefix(rest, "=") && len(challenges) > 0 {
+			// a parameter of the current challenge
+			var value string
+			value, s = readValue(strings.TrimLeft(rest[1:], " \t"))
+			challenges[len(challenges)-1].params[strings.ToLower(name)] = value
+			continue
+		}
+
+		// the name of a new auth-scheme
+		challenges = append(challenges, authChallenge{scheme: name, params: map[string]string{}})
+	}
+}
+
+func skipSeparators(s string) string {
+	return strings.TrimLeft(s, " \t,")
+}
+
+func isTokenChar(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+}
+
+func readToken(s string) (token, rest string) {
+	i := 0
+	for i < len(s) && isTokenChar(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// readValue reads a token or a quoted string, removing the quotes and escapes.
+func readValue(s string) (value, rest string) {
+	if !strings.HasPrefix(s, `"`) {
+		return readToken(s)
+	}
+
+	b := &strings.Builder{}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+			}
+		case '"':
+			return b.String(), s[i+1:]
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	// unterminated quoted string
+	return b.String(), ""
+}
+
+// find returns the first challenge with the given scheme, ignoring case.
+func find(challenges []authChallenge, scheme string) (authChallenge, bool) {
+	for _, c := range challenges {
+		if strings.EqualFold(c.scheme, scheme) {
+			return c, true
+		}
+	}
+	return authChallenge{}, false
+}
+
+// Offers reports whether any of the Www-Authenticate header values holds a
+// challenge for the given scheme, such as "Digest". Scheme names are not case
+// sensitive.
+func Offers(wwwAuthenticate []string, scheme string) bool {
+	for _, h := range wwwAuthenticate {
+		if _, ok := find(parseChallenges(h), scheme); ok {
+			return true
+		}
+	}
+	return false
+}
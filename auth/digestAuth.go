@@ -1,13 +1,16 @@
 package auth
 
 import (
-	md5pkg "crypto/md5"
+	"crypto/md5"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	hashpkg "hash"
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 )
 
 var _ Authenticator = &DigestAuth{}
@@ -26,7 +29,9 @@ func Digest(user string, pw string) *DigestAuth {
 type DigestAuth struct {
 	user        string
 	pw          string
+	mu          sync.Mutex
 	digestParts map[string]string
+	nonceCount  uint32
 }
 
 // Type identifies the Digest authenticator.
@@ -44,35 +49,69 @@ func (d *DigestAuth) Password() string {
 	return d.pw
 }
 
-// Authorize the current request.
-func (d *DigestAuth) Authorize(req *http.Request) {
+// Authorize the current request. Each request uses the next nonce count.
+func (d *DigestAuth) Authorize(req *http.Request) error {
+	d.mu.Lock()
+	d.nonceCount++
 	d.digestParts["uri"] = req.URL.Path
 	d.digestParts["method"] = req.Method
 	d.digestParts["username"] = d.user
 	d.digestParts["password"] = d.pw
-	req.Header.Set("Authorization", getDigestAuthorization(d.digestParts))
+	authorization := getDigestAuthorization(d.digestParts, d.nonceCount, getCnonce())
+	d.mu.Unlock()
+
+	req.Header.Set("Authorization", authorization)
+	return nil
 }
 
+// DigestParts installs the Digest challenge from a Www-Authenticate header,
+// which may also hold challenges for other schemes. This restarts the nonce count.
 func (d *DigestAuth) DigestParts(wwwAuthenticateHeader string) Authenticator {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.nonceCount = 0
 	d.digestParts = map[string]string{}
-	if len(wwwAuthenticateHeader) > 0 {
-		// unwanted headers: domain, stale, charset, userhash
-		wantedHeaders := []string{"nonce", "realm", "qop", "opaque", "algorithm", "entityBody"}
-		responseHeaders := strings.Split(wwwAuthenticateHeader, ",")
-		for _, r := range responseHeaders {
-			for _, w := range wantedHeaders {
-				if strings.Contains(r, w) {
-					value := strings.SplitN(r, `=`, 2)[1]
-					d.digestParts[w] = strings.Trim(value, `"`)
-				}
+	if ch, ok := find(parseChallenges(wwwAuthenticateHeader), "Digest"); ok {
+		// unwanted parameters: domain, stale, charset, userhash
+		for _, w := range []string{"nonce", "realm", "qop", "opaque", "algorithm", "entityBody"} {
+			if v, exists := ch.params[strings.ToLower(w)]; exists {
+				d.digestParts[w] = v
 			}
 		}
+		if qop, exists := d.digestParts["qop"]; exists {
+			d.digestParts["qop"] = chooseQop(qop)
+		}
 	}
 	return d
 }
 
-func md5(text string) string {
-	hasher := md5pkg.New()
+// chooseQop picks one of the comma-separated qop options offered by the server,
+// preferring "auth" because the body is not available for "auth-int".
+func chooseQop(options string) string {
+	var first string
+	for _, o := range strings.Split(options, ",") {
+		o = strings.TrimSpace(o)
+		if strings.EqualFold(o, "auth") {
+			return "auth"
+		}
+		if first == "" {
+			first = o
+		}
+	}
+	return first
+}
+
+// hash applies the digest algorithm named in the challenge to text. The "-sess"
+// variants use the same hash function as their base algorithm.
+func hash(algorithm, text string) string {
+	var hasher hashpkg.Hash
+	switch strings.TrimSuffix(strings.ToUpper(algorithm), "-SESS") {
+	case "SHA-256":
+		hasher = sha256.New()
+	default:
+		hasher = md5.New()
+	}
 	hasher.Write([]byte(text))
 	return hex.EncodeToString(hasher.Sum(nil))
 }
@@ -83,42 +122,37 @@ func getCnonce() string {
 	return fmt.Sprintf("%x", b)[:16]
 }
 
-func getDigestAuthorization(d map[string]string) string {
+func getDigestAuthorization(d map[string]string, nc uint32, cnonce string) string {
 	// These are the correct ha1 and ha2 for qop=auth. We should probably check for other types of qop.
 
 	var (
 		ha1        string
 		ha2        string
-		nonceCount = 00000001
-		cnonce     = getCnonce()
+		nonceCount = fmt.Sprintf("%08x", nc)
+		algorithm  = d["algorithm"]
 		response   string
 	)
 
 	// 'ha1' value depends on value of "algorithm" field
-	switch d["algorithm"] {
-	case "MD5", "":
-		ha1 = md5(d["username"] + ":" + d["realm"] + ":" + d["password"])
-	case "MD5-sess":
-		ha1 = md5(fmt.Sprintf("%s:%v:%s",
-			md5(d["username"]+":"+d["realm"]+":"+d["password"]),
-			nonceCount,
-			cnonce))
+	ha1 = hash(algorithm, d["username"]+":"+d["realm"]+":"+d["password"])
+	if strings.HasSuffix(strings.ToLower(algorithm), "-sess") {
+		ha1 = hash(algorithm, ha1+":"+d["nonce"]+":"+cnonce)
 	}
 
 	// 'ha2' value depends on value of "qop" field
 	switch d["qop"] {
 	case "auth", "":
-		ha2 = md5(d["method"] + ":" + d["uri"])
+		ha2 = hash(algorithm, d["method"]+":"+d["uri"])
 	case "auth-int":
 		if d["entityBody"] != "" {
-			ha2 = md5(d["method"] + ":" + d["uri"] + ":" + md5(d["entityBody"]))
+			ha2 = hash(algorithm, d["method"]+":"+d["uri"]+":"+hash(algorithm, d["entityBody"]))
 		}
 	}
 
 	// 'response' value depends on value of "qop" field
 	switch d["qop"] {
 	case "":
-		response = md5(
+		response = hash(algorithm,
 			fmt.Sprintf("%s:%s:%s",
 				ha1,
 				d["nonce"],
@@ -126,7 +160,7 @@ func getDigestAuthorization(d map[string]string) string {
 			),
 		)
 	case "auth", "auth-int":
-		response = md5(
+		response = hash(algorithm,
 			fmt.Sprintf("%s:%s:%v:%s:%s:%s",
 				ha1,
 				d["nonce"],
@@ -141,6 +175,10 @@ func getDigestAuthorization(d map[string]string) string {
 	authorization := fmt.Sprintf(`Digest username="%s", realm="%s", nonce="%s", uri="%s", nc=%v, cnonce="%s", response="%s"`,
 		d["username"], d["realm"], d["nonce"], d["uri"], nonceCount, cnonce, response)
 
+	if algorithm != "" {
+		authorization += fmt.Sprintf(`, algorithm=%s`, algorithm)
+	}
+
 	if d["qop"] != "" {
 		authorization += fmt.Sprintf(`, qop=%s`, d["qop"])
 	}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+const challenge = `Digest realm="test", nonce="dcd98b7102dd2f0e8b11d0f600bfb0c093", qop="auth", opaque="5ccc069c403ebaf9f0171e9517f40e41"`
+
+func TestDigestNonceCount(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	d := Digest("Mufasa", "Circle Of Life")
+	d.DigestParts(challenge)
+
+	rq1, _ := http.NewRequest(http.MethodGet, "http://example.com/dir/index.html", nil)
+	d.Authorize(rq1)
+	g.Expect(rq1.Header.Get("Authorization")).To(ContainSubstring("nc=00000001,"))
+
+	rq2, _ := http.NewRequest(http.MethodGet, "http://example.com/dir/index.html", nil)
+	d.Authorize(rq2)
+	g.Expect(rq2.Header.Get("Authorization")).To(ContainSubstring("nc=00000002,"))
+
+	// a new challenge restarts the count
+	d.DigestParts(challenge)
+
+	rq3, _ := http.NewRequest(http.MethodGet, "http://example.com/dir/index.html", nil)
+	d.Authorize(rq3)
+	g.Expect(rq3.Header.Get("Authorization")).To(ContainSubstring("nc=00000001,"))
+}
+
+// The examples in https://tools.ietf.org/html/rfc7616#section-3.9.1
+func TestDigestAuthorization_RFC7616(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cases := map[string]string{
+		"MD5":     "8ca523f5e9506fed4657c9700eebdbec",
+		"SHA-256": "753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1",
+	}
+
+	for algorithm, expected := range cases {
+		parts := map[string]string{
+			"username":  "Mufasa",
+			"password":  "Circle of Life",
+			"realm":     "http-auth@example.org",
+			"nonce":     "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v",
+			"opaque":    "FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS",
+			"qop":       "auth",
+			"algorithm": algorithm,
+			"method":    "GET",
+			"uri":       "/dir/index.html",
+		}
+
+		authorization := getDigestAuthorization(parts, 1, "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ")
+		g.Expect(authorization).To(ContainSubstring(`response="`+expected+`"`), algorithm)
+		g.Expect(authorization).To(ContainSubstring(`algorithm=`+algorithm), algorithm)
+	}
+}
+
+func TestDigestParts(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cases := map[string]map[string]string{
+		`Digest realm="test", nonce="abc", qop="auth", opaque="xyz"`: {
+			"realm": "test", "nonce": "abc", "qop": "auth", "opaque": "xyz",
+		},
+		`Digest realm="a, b and c",nonce="abc",qop="auth,auth-int",algorithm=SHA-256`: {
+			"realm": "a, b and c", "nonce": "abc", "qop": "auth", "algorithm": "SHA-256",
+		},
+		`Digest qop="auth-int, auth" , nextnonce="wrong", NONCE = "abc", domain="/a,/b", realm="say \"hi\""`: {
+			"realm": `say "hi"`, "nonce": "abc", "qop": "auth",
+		},
+		`Basic realm="basic, realm", Digest realm="digest", nonce="abc", qop="auth-int"`: {
+			"realm": "digest", "nonce": "abc", "qop": "auth-int",
+		},
+		`Basic realm="no digest here", nonce="abc"`: {},
+	}
+
+	for header, expected := range cases {
+		d := Digest("Mufasa", "Circle Of Life")
+		d.DigestParts(header)
+		g.Expect(d.digestParts).To(Equal(expected), header)
+	}
+}
+
+func TestParseChallenges(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	challenges := parseChallenges(`Newauth realm="apps", type=1, title="Login to \"apps\"", Basic realm="simple"`)
+	g.Expect(challenges).To(Equal([]authChallenge{
+		{scheme: "Newauth", params: map[string]string{"realm": "apps", "type": "1", "title": `Login to "apps"`}},
+		{scheme: "Basic", params: map[string]string{"realm": "simple"}},
+	}))
+
+	g.Expect(parseChallenges("")).To(BeEmpty())
+	g.Expect(parseChallenges(`Digest realm="unterminated`)).To(Equal([]authChallenge{
+		{scheme: "Digest", params: map[string]string{"realm": "unterminated"}},
+	}))
+}
+
+func TestOffers(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	headers := []string{`Basic realm="digest"`, `Bearer, Negotiate`}
+	g.Expect(Offers(headers, "basic")).To(BeTrue())
+	g.Expect(Offers(headers, "Negotiate")).To(BeTrue())
+	g.Expect(Offers(headers, "Digest")).To(BeFalse())
+	g.Expect(Offers(nil, "Basic")).To(BeFalse())
+}
@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"net/http"
+
+	"golang.org/x/oauth2"
+)
+
+// OAuth2 implements authentication using tokens from an OAuth2 token source,
+// for example one created by golang.org/x/oauth2/clientcredentials. Tokens are
+// cached and are refreshed from the source when they expire.
+//
+// This authenticator sets the Authorization header itself, so it should be used
+// with an ordinary HttpClient (see gowebdav.SetHttpClient), not with the client
+// returned by oauth2.NewClient, which would authorize every request a second time.
+func OAuth2(tokenSource oauth2.TokenSource) Authenticator {
+	return &oauth2Auth{
+		ts: oauth2.ReuseTokenSource(nil, tokenSource),
+	}
+}
+
+// oauth2Auth holds the token source.
+type oauth2Auth struct {
+	ts oauth2.TokenSource
+}
+
+// Type identifies the OAuth2 authenticator.
+func (o *oauth2Auth) Type() string {
+	return "OAuth2"
+}
+
+// User is not used by the OAuth2 authenticator.
+func (o *oauth2Auth) User() string {
+	return ""
+}
+
+// Password is not used by the OAuth2 authenticator.
+func (o *oauth2Auth) Password() string {
+	return ""
+}
+
+// Authorize the current request, failing if no token could be obtained.
+func (o *oauth2Auth) Authorize(req *http.Request) error {
+	token, err := o.ts.Token()
+	if err != nil {
+		return err
+	}
+	token.SetAuthHeader(req)
+	return nil
+}
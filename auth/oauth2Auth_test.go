@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"golang.org/x/oauth2"
+)
+
+type stubTokenSource struct {
+	tokens []*oauth2.Token
+	err    error
+}
+
+func (s *stubTokenSource) Token() (*oauth2.Token, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	t := s.tokens[0]
+	s.tokens = s.tokens[1:]
+	return t, nil
+}
+
+func TestOAuth2(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ts := &stubTokenSource{tokens: []*oauth2.Token{
+		{AccessToken: "expired", TokenType: "Bearer", Expiry: time.Now().Add(-time.Minute)},
+		{AccessToken: "fresh", TokenType: "Bearer", Expiry: time.Now().Add(time.Hour)},
+	}}
+	o := OAuth2(ts)
+
+	rq1, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+	g.Expect(o.Authorize(rq1)).NotTo(HaveOccurred())
+	g.Expect(rq1.Header.Get("Authorization")).To(Equal("Bearer expired"))
+
+	// the first token has expired so it is refreshed
+	rq2, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+	g.Expect(o.Authorize(rq2)).NotTo(HaveOccurred())
+	g.Expect(rq2.Header.Get("Authorization")).To(Equal("Bearer fresh"))
+}
+
+func TestOAuth2_error(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	o := OAuth2(&stubTokenSource{err: errors.New("no token")})
+
+	rq, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+	g.Expect(o.Authorize(rq)).To(MatchError("no token"))
+	g.Expect(rq.Header.Get("Authorization")).To(BeEmpty())
+}
@@ -16,43 +16,94 @@ import (
 	"time"
 )
 
-func SAML(user, pw, siteURL string, hc httpclient.HttpClient) Authenticator {
-	return &samlAuth{
-		user:    user,
-		pw:      pw,
-		siteURL: siteURL,
-		hc:      hc,
+const (
+	defaultUserRealmURL = "https://login.microsoftonline.com/GetUserRealm.srf"
+	defaultSTSURL       = "https://login.microsoftonline.com/extSTS.srf"
+)
+
+// SAMLOptions holds optional settings for SAML authentication. The zero value
+// is suitable for SharePoint Online in the global Microsoft cloud.
+type SAMLOptions struct {
+	// HttpClient is used for the token requests. The default is http.DefaultClient.
+	// If it is an *http.Client, a copy that does not follow redirects is used.
+	HttpClient httpclient.HttpClient
+
+	// UserRealmURL overrides the endpoint that determines whether the user's
+	// account is managed or federated.
+	UserRealmURL string
+
+	// STSURL overrides the security token service endpoint, for example for
+	// national clouds.
+	STSURL string
+}
+
+// SAML implements SharePoint Online authentication. A security token is obtained
+// from the Microsoft STS, using ADFS first for federated accounts, and exchanged
+// for the FedAuth and rtFa cookies that are then sent with each request. The
+// cookies are cached until shortly before the token expires. The options may be nil.
+func SAML(user, pw, siteURL string, opts *SAMLOptions) Authenticator {
+	sa := &samlAuth{
+		user:         user,
+		pw:           pw,
+		siteURL:      siteURL,
+		hc:           http.DefaultClient,
+		userRealmURL: defaultUserRealmURL,
+		stsURL:       defaultSTSURL,
+	}
+
+	if opts != nil {
+		if opts.HttpClient != nil {
+			sa.hc = opts.HttpClient
+		}
+		if opts.UserRealmURL != "" {
+			sa.userRealmURL = opts.UserRealmURL
+		}
+		if opts.STSURL != "" {
+			sa.stsURL = opts.STSURL
+		}
+	}
+
+	if hc, ok := sa.hc.(*http.Client); ok {
+		cp := *hc
+		cp.CheckRedirect = doNotCheckRedirect
+		sa.hc = &cp
 	}
+
+	return sa
 }
 
 type samlAuth struct {
-	user    string
-	pw      string
-	siteURL string
-	hc      httpclient.HttpClient
+	user         string
+	pw           string
+	siteURL      string
+	hc           httpclient.HttpClient
+	userRealmURL string
+	stsURL       string
 }
 
-// Type identifies the Basic authenticator.
+// Type identifies the SAML authenticator.
 func (sa *samlAuth) Type() string {
 	return "SAML"
 }
 
-// User holds the BasicAuth username.
+// User holds the SAML username.
 func (sa *samlAuth) User() string {
 	return sa.user
 }
 
-// pw holds the BasicAuth password.
+// Password holds the SAML password.
 func (sa *samlAuth) Password() string {
 	return sa.pw
 }
 
 // Authorize the current request.
-func (sa *samlAuth) Authorize(req *http.Request) {
+func (sa *samlAuth) Authorize(req *http.Request) error {
 	authCookie, _, err := sa.getAuth()
-	if err == nil {
-		req.Header.Set("Cookie", authCookie)
+	if err != nil {
+		return err
 	}
+	req.Header.Set("Cookie", authCookie)
+	return nil
 }
 
 func (sa *samlAuth) post(url, contentType string, body io.Reader) (resp *http.Response, err error) {
@@ -65,10 +116,6 @@ func (sa *samlAuth) post(url, contentType string, body io.Reader) (resp *http.Re
 }
 
 func (sa *samlAuth) getAuth() (string, int64, error) {
-	if sa.hc == nil {
-		sa.hc = http.DefaultClient
-	}
-
 	parsedURL, err := url.Parse(sa.siteURL)
 	if err != nil {
 		return "", 0, err
@@ -84,6 +131,10 @@ func (sa *samlAuth) getAuth() (string, int64, error) {
 		return "", 0, err
 	}
 
+	if authCookie == "" {
+		return "", 0, errors.New("no FedAuth or rtFa cookie was issued")
+	}
+
 	notAfterTime, _ := time.Parse(time.RFC3339, notAfter)
 	expiry := time.Until(notAfterTime) - 60*time.Second
 	exp := time.Now().Add(expiry).Unix()
@@ -94,18 +145,10 @@ func (sa *samlAuth) getAuth() (string, int64, error) {
 }
 
 func getSecurityToken(sa *samlAuth) (string, string, error) {
-	if sa.hc == nil {
-		sa.hc = http.DefaultClient
-	}
-
-	const endpoint = "https://login.microsoftonline.com/GetUserRealm.srf"
-
 	params := url.Values{}
 	params.Set("login", sa.user)
 
-	sa.hc.(*http.Client).CheckRedirect = doNotCheckRedirect
-
-	resp, err := sa.post(endpoint, "application/x-www-form-urlencoded", strings.NewReader(params.Encode()))
+	resp, err := sa.post(sa.userRealmURL, "application/x-www-form-urlencoded", strings.NewReader(params.Encode()))
 	if err != nil {
 		return "", "", err
 	}
@@ -155,10 +198,6 @@ func getSecurityToken(sa *samlAuth) (string, string, error) {
 }
 
 func getSecurityTokenWithOnline(sa *samlAuth) (string, string, error) {
-	if sa.hc == nil {
-		sa.hc = http.DefaultClient
-	}
-
 	parsedURL, err := url.Parse(sa.siteURL)
 	if err != nil {
 		return "", "", err
@@ -170,17 +209,13 @@ func getSecurityTokenWithOnline(sa *samlAuth) (string, string, error) {
 		return "", "", err
 	}
 
-	stsEndpoint := "https://login.microsoftonline.com/extSTS.srf" // TODO: add mapping for diff SPOs
-
-	req, err := http.NewRequest("POST", stsEndpoint, bytes.NewBuffer([]byte(samlBody)))
+	req, err := http.NewRequest("POST", sa.stsURL, bytes.NewBuffer([]byte(samlBody)))
 	if err != nil {
 		return "", "", err
 	}
 
 	req.Header.Set("Content-Type", "application/soap+xml;charset=utf-8")
 
-	sa.hc.(*http.Client).CheckRedirect = doNotCheckRedirect
-
 	resp, err := sa.hc.Do(req)
 	if err != nil {
 		return "", "", err
@@ -211,6 +246,14 @@ func getSecurityTokenWithOnline(sa *samlAuth) (string, string, error) {
 		return "", "", err
 	}
 
+	if result.Fault != "" {
+		return "", "", errors.New(result.Fault)
+	}
+
+	if result.Response.BinaryToken == "" {
+		return "", "", errors.New("can't extract binary token")
+	}
+
 	resp, err = sa.post(formsEndpoint, "application/x-www-form-urlencoded", strings.NewReader(result.Response.BinaryToken))
 	if err != nil {
 		return "", "", err
@@ -225,25 +268,10 @@ func getSecurityTokenWithOnline(sa *samlAuth) (string, string, error) {
 		return "", "", err
 	}
 
-	// cookie := resp.Header.Get("Set-Cookie") // TODO: parse FedAuth and rtFa cookies only (?)
-	// fmt.Printf("Cookie: %s\n", cookie)
-	// fmt.Printf("Resp2, %v\n", resp.StatusCode)
-
-	var authCookie string
-	for _, coo := range resp.Cookies() {
-		if coo.Name == "rtFa" || coo.Name == "FedAuth" {
-			authCookie += coo.String() + "; "
-		}
-	}
-
-	return authCookie, result.Response.Lifetime.Expires, nil
+	return authCookies(resp), result.Response.Lifetime.Expires, nil
 }
 
 func getSecurityTokenWithAdfs(adfsURL string, sa *samlAuth) (string, string, error) {
-	if sa.hc == nil {
-		sa.hc = http.DefaultClient
-	}
-
 	parsedAdfsURL, err := url.Parse(adfsURL)
 	if err != nil {
 		return "", "", err
@@ -255,7 +283,6 @@ func getSecurityTokenWithAdfs(adfsURL string, sa *samlAuth) (string, string, err
 		return "", "", err
 	}
 
-	sa.hc.(*http.Client).CheckRedirect = doNotCheckRedirect
 	resp, err := sa.post(usernameMixedURL, "application/soap+xml;charset=utf-8", bytes.NewBuffer([]byte(samlBody)))
 	if err != nil {
 		return "", "", err
@@ -304,9 +331,7 @@ func getSecurityTokenWithAdfs(adfsURL string, sa *samlAuth) (string, string, err
 		return "", "", err
 	}
 
-	stsEndpoint := "https://login.microsoftonline.com/extSTS.srf" // TODO: mapping
-
-	resp, err = sa.post(stsEndpoint, "application/soap+xml;charset=utf-8", bytes.NewBuffer([]byte(tokenRequest)))
+	resp, err = sa.post(sa.stsURL, "application/soap+xml;charset=utf-8", bytes.NewBuffer([]byte(tokenRequest)))
 	if err != nil {
 		return "", "", err
 	}
@@ -341,8 +366,6 @@ func getSecurityTokenWithAdfs(adfsURL string, sa *samlAuth) (string, string, err
 		return "", "", errors.New("can't extract binary token")
 	}
 
-	sa.hc.(*http.Client).CheckRedirect = doNotCheckRedirect
-
 	formsEndpoint := fmt.Sprintf("%s://%s/_forms/default.aspx?wa=wsignin1.0", parsedURL.Scheme, parsedURL.Host)
 	resp, err = sa.post(formsEndpoint, "application/x-www-form-urlencoded", strings.NewReader(tokenResult.Response.BinaryToken))
 	if err != nil {
@@ -358,14 +381,19 @@ func getSecurityTokenWithAdfs(adfsURL string, sa *samlAuth) (string, string, err
 		return "", "", err
 	}
 
-	var authCookie string
+	return authCookies(resp), tokenResult.Response.Lifetime.Expires, nil
+}
+
+// authCookies formats the FedAuth and rtFa cookies set by the response as the
+// value of a Cookie request header.
+func authCookies(resp *http.Response) string {
+	var pairs []string
 	for _, coo := range resp.Cookies() {
 		if coo.Name == "rtFa" || coo.Name == "FedAuth" {
-			authCookie += coo.String() + "; "
+			pairs = append(pairs, coo.Name+"="+coo.Value)
 		}
 	}
-
-	return authCookie, tokenResult.Response.Lifetime.Expires, nil
+	return strings.Join(pairs, "; ")
 }
 
 // doNotCheckRedirect *http.Client CheckRedirect callback to ignore redirects
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestSAML_managed(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	stsResponse, err := ioutil.ReadFile("testdata/extSTS_response.xml")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	var token string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.Method).To(Equal(http.MethodPost))
+		body, _ := ioutil.ReadAll(r.Body)
+
+		switch r.URL.Path {
+		case "/GetUserRealm.srf":
+			g.Expect(string(body)).To(Equal("login=user1%40example.com"))
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"State":4,"UserState":1,"Login":"user1@example.com","NameSpaceType":"Managed"}`))
+
+		case "/extSTS.srf":
+			g.Expect(string(body)).To(ContainSubstring("<o:Username>user1@example.com</o:Username>"))
+			w.Header().Set("Content-Type", "application/soap+xml; charset=utf-8")
+			w.Write(stsResponse)
+
+		case "/_forms/default.aspx":
+			token = string(body)
+			http.SetCookie(w, &http.Cookie{Name: "rtFa", Value: "rtfa-value", Path: "/", HttpOnly: true})
+			http.SetCookie(w, &http.Cookie{Name: "FedAuth", Value: "fedauth-value", Path: "/", HttpOnly: true})
+			http.Redirect(w, r, "/", http.StatusFound)
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	sa := SAML("user1@example.com", "secret", server.URL+"/sites/alpha", &SAMLOptions{
+		HttpClient:   server.Client(),
+		UserRealmURL: server.URL + "/GetUserRealm.srf",
+		STSURL:       server.URL + "/extSTS.srf",
+	})
+	g.Expect(sa.Type()).To(Equal("SAML"))
+
+	rq, _ := http.NewRequest(http.MethodGet, server.URL+"/sites/alpha/", nil)
+	g.Expect(sa.Authorize(rq)).NotTo(HaveOccurred())
+	g.Expect(token).To(Equal("t=EwBgAk6hB0GZbMm1EXAMPLETOKEN&p="))
+	g.Expect(rq.Header.Get("Cookie")).To(Equal("rtFa=rtfa-value; FedAuth=fedauth-value"))
+
+	// the supplied client is not altered
+	g.Expect(server.Client().CheckRedirect).To(BeNil())
+}
+
+func TestSAML_fault(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/GetUserRealm.srf":
+			w.Write([]byte(`{"NameSpaceType":"Managed"}`))
+		default:
+			w.Write([]byte(`<S:Envelope xmlns:S="http://www.w3.org/2003/05/soap-envelope">
+				<S:Body><S:Fault><S:Reason><S:Text xml:lang="en-US">Authentication Failure</S:Text></S:Reason></S:Fault></S:Body>
+			</S:Envelope>`))
+		}
+	}))
+	defer server.Close()
+
+	sa := SAML("user2@example.com", "wrong", server.URL+"/sites/alpha", &SAMLOptions{
+		UserRealmURL: server.URL + "/GetUserRealm.srf",
+		STSURL:       server.URL + "/extSTS.srf",
+	})
+
+	rq, _ := http.NewRequest(http.MethodGet, server.URL+"/sites/alpha/", nil)
+	g.Expect(sa.Authorize(rq)).To(MatchError("Authentication Failure"))
+}
@@ -0,0 +1,88 @@
+package gowebdav
+
+import (
+	"net/url"
+	"strings"
+)
+
+// SetBasePath sets the path at which the WebDAV tree is mounted on the server,
+// such as /remote.php/dav/files/alice, replacing any path in the URL given to
+// NewClient. The path is not escaped. This lets the root be given as just the
+// host, e.g.
+//
+//	gowebdav.NewClient("https://example.com", gowebdav.SetBasePath("/remote.php/dav/files/alice"))
+//
+// All paths used with the client are relative to the base path. It is added to
+// request URLs and Destination headers, and removed from the hrefs in the
+// server's responses.
+func SetBasePath(basePath string) ClientOpt {
+	return func(c Client) {
+		cl := c.(*client)
+		u, err := url.Parse(cl.root)
+		if err != nil {
+			return
+		}
+		u.Path = withoutTrailingSlash(withLeadingSlash(basePath))
+		u.RawPath = ""
+		cl.root = u.String()
+	}
+}
+
+// BasePath gets the path at which the WebDAV tree is mounted on the server,
+// without any trailing slash. It is empty if the tree is at the root of the host.
+func (c *client) BasePath() string {
+	u, err := url.Parse(c.getRoot())
+	if err != nil {
+		return ""
+	}
+	return withoutTrailingSlash(u.Path)
+}
+
+// URL gets the absolute URL of path, which is relative to the root, as the client
+// would use it in a request. The path is escaped as needed, for example with %20
+// for a space. This suits links to give to other tools.
+func (c *client) URL(path string) string {
+	return c.resourceURL(withLeadingSlash(path))
+}
+
+// hrefPath converts a href in a server response to a path relative to the root.
+// The href may be a path or an absolute URL; either way, it normally starts with
+// the base path.
+func (c *client) hrefPath(href string) string {
+	p := href
+	if u, err := url.Parse(href); err == nil {
+		p = u.Path
+	}
+
+	if base := c.BasePath(); base != "" {
+		if p == base {
+			return "/"
+		}
+		if strings.HasPrefix(p, base+"/") {
+			p = p[len(base):]
+		}
+	}
+
+	return withLeadingSlash(p)
+}
+
+// hrefUnder gets the path of href relative to the collection dirHref, both being
+// hrefs from the same response, without any leading or trailing slash. Comparing
+// hrefs with each other rather than with the root means that any prefix at which
+// the server is mounted, which may differ from the base path when there is a proxy
+// in between, is ignored. It returns false if href is not under dirHref.
+func hrefUnder(dirHref, href string) (string, bool) {
+	dir, err1 := url.Parse(dirHref)
+	u, err2 := url.Parse(href)
+	if err1 != nil || err2 != nil {
+		return "", false
+	}
+
+	prefix := withTrailingSlash(dir.Path)
+	if !strings.HasPrefix(u.Path, prefix) {
+		return "", false
+	}
+
+	rel := strings.Trim(u.Path[len(prefix):], "/")
+	return rel, rel != ""
+}
@@ -0,0 +1,71 @@
+package gowebdav_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/rickb777/gowebdav"
+	"golang.org/x/net/webdav"
+)
+
+func TestSetBasePath(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// the hrefs in this server's responses include the full base path
+	var destinations []string
+	handler := &webdav.Handler{
+		Prefix:     "/remote.php/dav/files/alice",
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if d := r.Header.Get("Destination"); d != "" {
+			destinations = append(destinations, strings.TrimPrefix(d, "http://"+r.Host))
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL+"/ignored", gowebdav.SetBasePath("/remote.php/dav/files/alice/"))
+	g.Expect(client.BasePath()).To(Equal("/remote.php/dav/files/alice"))
+	g.Expect(client.Name()).To(Equal("webdav:" + server.URL + "/remote.php/dav/files/alice"))
+	g.Expect(gowebdav.NewClient(server.URL).BasePath()).To(Equal(""))
+
+	must(t, client.MkdirAll("docs/old", 0755))
+	must(t, client.WriteFile("docs/a.txt", []byte("hello"), 0644))
+	must(t, client.Rename("docs/a.txt", "docs/old/b.txt"))
+	g.Expect(destinations).To(Equal([]string{"/remote.php/dav/files/alice/docs/old/b.txt"}))
+
+	files, err := client.ReadDir("docs/old")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(files).To(HaveLen(1))
+	g.Expect(files[0].Name()).To(Equal("b.txt"))
+	g.Expect(files[0].(interface{ Path() string }).Path()).To(Equal("/docs/old/b.txt"))
+
+	var visited []string
+	err = client.Walk("/", func(p string, info os.FileInfo, err error) error {
+		visited = append(visited, p)
+		return err
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(visited).To(Equal([]string{"/", "/docs", "/docs/old", "/docs/old/b.txt"}))
+
+	files, err = client.ReadDirInfinite("docs")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(files).To(HaveLen(2))
+}
+
+func TestURL(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	client := gowebdav.NewClient("https://example.com/dav files", gowebdav.SetBasePath("/remote.php/dav/files/al ice"))
+	g.Expect(client.URL("docs/a b#1?.txt")).To(Equal("https://example.com/remote.php/dav/files/al%20ice/docs/a%20b%231%3F.txt"))
+	g.Expect(client.URL("/")).To(Equal("https://example.com/remote.php/dav/files/al%20ice/"))
+
+	client = gowebdav.NewClient("https://example.com/dav%20files/")
+	g.Expect(client.URL("100%.txt")).To(Equal("https://example.com/dav%20files/100%25.txt"))
+}
@@ -0,0 +1,68 @@
+package gowebdav
+
+import (
+	"sync"
+)
+
+const defaultBatchConcurrency = 4
+
+// SetBatchConcurrency sets how many requests are in flight at once during batch
+// operations such as RemoveMany. The default is 4.
+func SetBatchConcurrency(n int) ClientOpt {
+	return func(c Client) {
+		if n > 0 {
+			c.(*client).batchConcurrency = n
+		}
+	}
+}
+
+// RemoveMany removes each of the remote paths, concurrently. Unlike a loop calling
+// Remove, it continues after a failure; as with Remove, paths that do not exist
+// are not treated as failures. If any fail, the error is a *BatchError listing the
+// paths that failed, in the given order, and why.
+func (c *client) RemoveMany(paths []string) error {
+	return batchError("RemoveMany", runBatch(paths, c.concurrency(), func(path string) error {
+		return c.RemoveAll(path)
+	}))
+}
+
+func (c *client) concurrency() int {
+	if c.batchConcurrency > 0 {
+		return c.batchConcurrency
+	}
+	return defaultBatchConcurrency
+}
+
+// runBatch calls fn for each path, with up to n calls in progress at once. It
+// returns the failures in the same order as paths.
+func runBatch(paths []string, n int, fn func(path string) error) []PathFailure {
+	errs := make([]error, len(paths))
+
+	sem := make(chan struct{}, n)
+	wg := &sync.WaitGroup{}
+	for i, p := range paths {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, p string) {
+			defer wg.Done()
+			errs[i] = fn(p)
+			<-sem
+		}(i, p)
+	}
+	wg.Wait()
+
+	var failures []PathFailure
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, PathFailure{Path: paths[i], Err: err})
+		}
+	}
+	return failures
+}
+
+func batchError(op string, failures []PathFailure) error {
+	if len(failures) > 0 {
+		return &BatchError{Op: op, Failures: failures}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package gowebdav_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/rickb777/gowebdav"
+)
+
+func TestRemoveMany(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	mu := &sync.Mutex{}
+	inFlight, maxInFlight := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		switch r.URL.Path {
+		case "/locked.txt":
+			w.WriteHeader(http.StatusLocked)
+		case "/forbidden.txt":
+			w.WriteHeader(http.StatusForbidden)
+		case "/missing.txt":
+			w.WriteHeader(http.StatusNotFound)
+		case "/changed.txt":
+			w.WriteHeader(http.StatusPreconditionFailed)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL, gowebdav.SetBatchConcurrency(2))
+
+	paths := []string{"a.txt", "locked.txt", "b.txt", "missing.txt", "forbidden.txt", "c.txt"}
+	err := client.RemoveMany(paths)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(maxInFlight).To(BeNumerically("<=", 2))
+
+	var be *gowebdav.BatchError
+	g.Expect(errors.As(err, &be)).To(BeTrue())
+	g.Expect(be.Failures).To(HaveLen(2))
+	g.Expect(be.Failures[0].Path).To(Equal("locked.txt"))
+	g.Expect(be.Failures[0].Err).To(MatchError("Remove /locked.txt: 423"))
+	g.Expect(be.Failures[1].Path).To(Equal("forbidden.txt"))
+	g.Expect(gowebdav.IsForbidden(be.Failures[1].Err)).To(BeTrue())
+
+	var se *gowebdav.StatusError
+	g.Expect(be.As(&se)).To(BeTrue())
+	g.Expect(se.StatusCode).To(Equal(http.StatusLocked))
+	g.Expect(be.Is(gowebdav.ErrPreconditionFailed)).To(BeFalse())
+
+	err = client.RemoveMany([]string{"a.txt", "changed.txt"})
+	g.Expect(errors.As(err, &be)).To(BeTrue())
+	g.Expect(be.Is(gowebdav.ErrPreconditionFailed)).To(BeTrue())
+
+	g.Expect(client.RemoveMany([]string{"a.txt", "missing.txt"})).To(Succeed())
+}
@@ -0,0 +1,104 @@
+package gowebdav
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"hash"
+	"hash/adler32"
+	"io"
+	"strings"
+)
+
+var checksumsProperty = xml.Name{Space: "http://owncloud.org/ns", Local: "checksums"}
+
+// checksumsResponse is a multistatus response element holding the checksums property.
+type checksumsResponse struct {
+	Propstats []struct {
+		Status string `xml:"DAV: status"`
+		Prop   struct {
+			Checksums []string `xml:"http://owncloud.org/ns checksums>checksum"`
+		} `xml:"DAV: prop"`
+	} `xml:"DAV: propstat"`
+}
+
+// Checksums gets the checksums that the server has recorded for a file, keyed by
+// algorithm, e.g. "SHA1", "MD5" and "ADLER32". This uses the {http://owncloud.org/ns}checksums
+// property, which is provided by ownCloud and Nextcloud for files uploaded with a
+// checksum (as their desktop clients do). Other servers give an empty result; in
+// that case, use VerifyDownload instead.
+func (c *client) Checksums(path string) (map[string]string, error) {
+	sums := make(map[string]string)
+	parse := func(resp interface{}) error {
+		r := resp.(*checksumsResponse)
+		for _, ps := range r.Propstats {
+			if strings.Contains(ps.Status, responseStatusOK) {
+				for _, cs := range ps.Prop.Checksums {
+					parseChecksums(cs, sums)
+				}
+			}
+		}
+
+		r.Propstats = nil
+		return nil
+	}
+
+	err := c.propfind("Checksums", path, 0, propfindBody(c.davPrefix, []xml.Name{checksumsProperty}), &checksumsResponse{}, parse)
+	if err != nil {
+		return nil, withOp("Checksums", path, err)
+	}
+	return sums, nil
+}
+
+// parseChecksums parses a space-separated list such as "SHA1:abc MD5:def".
+func parseChecksums(s string, sums map[string]string) {
+	for _, field := range strings.Fields(s) {
+		if i := strings.IndexByte(field, ':'); i > 0 {
+			sums[strings.ToUpper(field[:i])] = strings.ToLower(field[i+1:])
+		}
+	}
+}
+
+// VerifyDownload downloads a file and checks it against the expected checksum,
+// given in hex, without keeping the content. The algorithm is "MD5", "SHA1",
+// "SHA256" or "ADLER32". If the checksum differs, the error wraps
+// ErrChecksumMismatch.
+func (c *client) VerifyDownload(path string, expected string, algo string) error {
+	h, err := newHash(algo)
+	if err != nil {
+		return newPathErrorErr("VerifyDownload", path, err)
+	}
+
+	stream, err := c.ReadStream(path)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	if _, err = io.Copy(h, stream); err != nil {
+		return newPathErrorErr("VerifyDownload", path, err)
+	}
+
+	actual := hex.EncodeToString(h.Sum(nil))
+	if !strings.EqualFold(actual, expected) {
+		return newPathErrorErr("VerifyDownload", path, fmt.Errorf("%w: %s %s, expected %s", ErrChecksumMismatch, algo, actual, expected))
+	}
+	return nil
+}
+
+func newHash(algo string) (hash.Hash, error) {
+	switch strings.ToUpper(algo) {
+	case "MD5":
+		return md5.New(), nil
+	case "SHA1":
+		return sha1.New(), nil
+	case "SHA256":
+		return sha256.New(), nil
+	case "ADLER32":
+		return adler32.New(), nil
+	}
+	return nil, fmt.Errorf("unsupported checksum algorithm %q", algo)
+}
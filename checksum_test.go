@@ -0,0 +1,72 @@
+package gowebdav_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/rickb777/gowebdav"
+	"golang.org/x/net/webdav"
+)
+
+func TestChecksums(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprint(w, `<?xml version="1.0"?>
+			<d:multistatus xmlns:d="DAV:" xmlns:oc="http://owncloud.org/ns">
+				<d:response>
+					<d:href>/a.txt</d:href>
+					<d:propstat>
+						<d:prop>
+							<oc:checksums>
+								<oc:checksum>SHA1:0a4d55a8d778e5022fab701977c5d840bbc486d0 MD5:b10a8db164e0754105b7a99be72e3fe5 ADLER32:180b041d</oc:checksum>
+							</oc:checksums>
+						</d:prop>
+						<d:status>HTTP/1.1 200 OK</d:status>
+					</d:propstat>
+				</d:response>
+			</d:multistatus>`)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	sums, err := client.Checksums("a.txt")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(sums).To(Equal(map[string]string{
+		"SHA1":    "0a4d55a8d778e5022fab701977c5d840bbc486d0",
+		"MD5":     "b10a8db164e0754105b7a99be72e3fe5",
+		"ADLER32": "180b041d",
+	}))
+}
+
+func TestVerifyDownload(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+	must(t, client.WriteFile("a.txt", []byte("Hello World"), 0644))
+
+	sums, err := client.Checksums("a.txt")
+	g.Expect(sums, err).To(BeEmpty())
+
+	must(t, client.VerifyDownload("a.txt", "0a4d55a8d778e5022fab701977c5d840bbc486d0", "SHA1"))
+	must(t, client.VerifyDownload("a.txt", "B10A8DB164E0754105B7A99BE72E3FE5", "md5"))
+	must(t, client.VerifyDownload("a.txt", "180b041d", "ADLER32"))
+
+	err = client.VerifyDownload("a.txt", "00000000", "ADLER32")
+	g.Expect(errors.Is(err, gowebdav.ErrChecksumMismatch)).To(BeTrue())
+
+	err = client.VerifyDownload("a.txt", "00000000", "CRC32")
+	g.Expect(err).To(MatchError(ContainSubstring("unsupported checksum algorithm")))
+}
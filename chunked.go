@@ -0,0 +1,154 @@
+package gowebdav
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultChunkSize is the chunk size used by ChunkedUpload when none is given.
+// Nextcloud requires every chunk except the last to be between 5MiB and 5GiB.
+const DefaultChunkSize = 10 << 20
+
+// ChunkedUpload uploads a large file using the chunked upload protocol (v2) of
+// Nextcloud and ownCloud, which avoids the timeouts that a single large PUT can
+// hit. It only works when the client's root is a Nextcloud files URL, such as
+// https://example.com/remote.php/dav/files/alice.
+//
+// The chunks are sent in sequence to a new upload collection under
+// remote.php/dav/uploads/alice, then assembled at path with a MOVE. If any step
+// fails, the upload collection is deleted. If chunkSize is zero or less,
+// DefaultChunkSize is used.
+func (c *client) ChunkedUpload(path string, stream io.Reader, totalSize int64, chunkSize int64) error {
+	path = withLeadingSlash(path)
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	uploads, err := uploadsURL(c.getRoot())
+	if err != nil {
+		return newPathErrorErr("ChunkedUpload", path, err)
+	}
+
+	if err = c.createParentCollection(path); err != nil {
+		return err
+	}
+
+	id, err := uploadID()
+	if err != nil {
+		return newPathErrorErr("ChunkedUpload", path, err)
+	}
+
+	uc := c.withRoot(uploads + "/" + id)
+	headers := func(rq *http.Request) {
+		rq.Header.Set("Destination", c.resourceURL(path))
+		rq.Header.Set("OC-Total-Length", strconv.FormatInt(totalSize, 10))
+	}
+
+	err = c.sendChunks(uc, path, stream, totalSize, chunkSize, headers)
+	if err != nil {
+		_ = uc.RemoveAll("/")
+		return err
+	}
+
+	c.InvalidateCache(path)
+	return nil
+}
+
+func (c *client) sendChunks(uc *client, path string, stream io.Reader, totalSize, chunkSize int64, headers func(*http.Request)) error {
+	s, err := uc.mkcol("ChunkedUpload", "/", headers)
+	if err != nil {
+		return newPathErrorErr("ChunkedUpload", path, err)
+	}
+	if s != http.StatusCreated {
+		return newPathError("ChunkedUpload", path, s)
+	}
+
+	var sent int64
+	for n := 1; n == 1 || sent < totalSize; n++ {
+		size := chunkSize
+		if remaining := totalSize - sent; remaining < size {
+			size = remaining
+		}
+
+		chunk := fmt.Sprintf("/%05d", n)
+		// the error names the chunk, which is wrapped to give the destination too
+		_, err = uc.put("ChunkedUpload", chunk, io.LimitReader(stream, size), func(rq *http.Request) {
+			rq.ContentLength = size
+			if size == 0 {
+				rq.Body = http.NoBody
+			}
+			headers(rq)
+		})
+		if err != nil {
+			return newPathErrorErr("ChunkedUpload", path, err)
+		}
+		sent += size
+	}
+
+	res, err := uc.request("ChunkedUpload", MethodMove, "/.file", nil, headers)
+	if err != nil {
+		return newPathErrorErr("ChunkedUpload", path, err)
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusCreated, http.StatusNoContent:
+		return nil
+	}
+	return responseError("ChunkedUpload", path, res)
+}
+
+// uploadsURL finds the Nextcloud uploads collection that corresponds to a files
+// root, for example .../remote.php/dav/uploads/alice for .../remote.php/dav/files/alice.
+func uploadsURL(root string) (string, error) {
+	u, err := url.Parse(root)
+	if err != nil {
+		return "", err
+	}
+
+	p := u.EscapedPath()
+	i := strings.LastIndex(p, "/dav/files/")
+	if i < 0 {
+		return "", errors.New("chunked upload needs a root ending .../dav/files/<user>")
+	}
+
+	user := strings.SplitN(p[i+len("/dav/files/"):], "/", 2)[0]
+	if user == "" {
+		return "", errors.New("chunked upload needs a root ending .../dav/files/<user>")
+	}
+
+	u.RawPath = p[:i] + "/dav/uploads/" + user
+	u.Path, _ = url.PathUnescape(u.RawPath)
+	return u.String(), nil
+}
+
+// uploadID makes a random name for an upload collection.
+func uploadID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "gowebdav-" + hex.EncodeToString(b), nil
+}
+
+// withRoot returns a client like c but with a different root. The configuration,
+// including the current authentication, is shared; the stat cache and the lock
+// tokens are not.
+func (c *client) withRoot(root string) *client {
+	c.authMutex.Lock()
+	auth := c.auth
+	c.authMutex.Unlock()
+
+	return &client{
+		root:         root,
+		auth:         auth,
+		clientConfig: c.clientConfig,
+	}
+}
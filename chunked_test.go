@@ -0,0 +1,148 @@
+package gowebdav_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/rickb777/gowebdav"
+)
+
+// nextcloud emulates the chunked upload protocol of a Nextcloud server.
+type nextcloud struct {
+	mu      sync.Mutex
+	log     []string
+	chunks  map[string][]byte
+	files   map[string][]byte
+	failPut bool
+	expects []string
+}
+
+func (nc *nextcloud) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+
+	const uploads = "/remote.php/dav/uploads/alice/"
+	nc.log = append(nc.log, r.Method+" "+strings.TrimPrefix(r.URL.Path, uploads)+" "+r.Header.Get("OC-Total-Length"))
+
+	switch {
+	case r.Method == "MKCOL" && strings.HasPrefix(r.URL.Path, uploads):
+		nc.chunks = make(map[string][]byte)
+		w.WriteHeader(http.StatusCreated)
+
+	case r.Method == "MKCOL":
+		w.WriteHeader(http.StatusCreated)
+
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, uploads):
+		if nc.failPut {
+			w.WriteHeader(http.StatusInsufficientStorage)
+			return
+		}
+		nc.expects = append(nc.expects, r.Header.Get("Expect"))
+		bs, _ := ioutil.ReadAll(r.Body)
+		nc.chunks[r.URL.Path] = bs
+		w.WriteHeader(http.StatusCreated)
+
+	case r.Method == "MOVE":
+		var names []string
+		for k := range nc.chunks {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		buf := &bytes.Buffer{}
+		for _, k := range names {
+			buf.Write(nc.chunks[k])
+		}
+		nc.files[r.Header.Get("Destination")] = buf.Bytes()
+		w.WriteHeader(http.StatusCreated)
+
+	case r.Method == http.MethodDelete:
+		nc.chunks = nil
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestChunkedUpload(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	nc := &nextcloud{files: make(map[string][]byte)}
+	server := httptest.NewServer(nc)
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL + "/remote.php/dav/files/alice")
+
+	data := strings.Repeat("0123456789", 25)
+	must(t, client.ChunkedUpload("docs/big file.bin", strings.NewReader(data), int64(len(data)), 100))
+
+	g.Expect(nc.files).To(HaveKey(server.URL + "/remote.php/dav/files/alice/docs/big%20file.bin"))
+	g.Expect(string(nc.files[server.URL+"/remote.php/dav/files/alice/docs/big%20file.bin"])).To(Equal(data))
+
+	// the parent collection, then the upload collection, three chunks and the assembly
+	g.Expect(nc.log).To(HaveLen(6))
+	g.Expect(nc.log[0]).To(Equal("MKCOL /remote.php/dav/files/alice/docs/ "))
+	g.Expect(nc.log[1]).To(MatchRegexp(`^MKCOL gowebdav-[0-9a-f]{32}/ 250$`))
+	g.Expect(nc.log[2]).To(MatchRegexp(`^PUT gowebdav-[0-9a-f]{32}/00001 250$`))
+	g.Expect(nc.log[4]).To(MatchRegexp(`^PUT gowebdav-[0-9a-f]{32}/00003 250$`))
+	g.Expect(nc.log[5]).To(MatchRegexp(`^MOVE gowebdav-[0-9a-f]{32}/.file 250$`))
+}
+
+func TestChunkedUpload_expectContinue(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	nc := &nextcloud{files: make(map[string][]byte)}
+	server := httptest.NewServer(nc)
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL+"/remote.php/dav/files/alice", gowebdav.EnableExpectContinue())
+
+	data := strings.Repeat("0123456789", 25)
+	must(t, client.ChunkedUpload("big.bin", strings.NewReader(data), int64(len(data)), 100))
+
+	g.Expect(nc.expects).To(Equal([]string{"100-continue", "100-continue", "100-continue"}))
+}
+
+func TestChunkedUpload_shortStream(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	nc := &nextcloud{files: make(map[string][]byte)}
+	server := httptest.NewServer(nc)
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL + "/remote.php/dav/files/alice")
+
+	data := strings.Repeat("0123456789", 15)
+	err := client.ChunkedUpload("big.bin", strings.NewReader(data), 250, 100)
+	g.Expect(err).To(MatchError(ContainSubstring("ChunkedUpload /00002")))
+	g.Expect(err).To(MatchError(ContainSubstring("ContentLength=100 with Body length 50")))
+	g.Expect(nc.log[len(nc.log)-1]).To(MatchRegexp(`^DELETE gowebdav-[0-9a-f]{32}/ $`))
+	g.Expect(nc.files).To(BeEmpty())
+}
+
+func TestChunkedUpload_failure(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	nc := &nextcloud{files: make(map[string][]byte), failPut: true}
+	server := httptest.NewServer(nc)
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL + "/remote.php/dav/files/alice")
+
+	err := client.ChunkedUpload("big.bin", strings.NewReader("data"), 4, 0)
+	g.Expect(gowebdav.IsInsufficientStorage(err)).To(BeTrue())
+	g.Expect(err).To(MatchError("ChunkedUpload /big.bin: ChunkedUpload /00001: 507"))
+	g.Expect(nc.log[len(nc.log)-1]).To(MatchRegexp(`^DELETE gowebdav-[0-9a-f]{32}/ $`))
+	g.Expect(nc.files).To(BeEmpty())
+
+	other := gowebdav.NewClient(server.URL + "/webdav")
+	err = other.ChunkedUpload("big.bin", strings.NewReader("data"), 4, 0)
+	g.Expect(err).To(MatchError(ContainSubstring("dav/files/<user>")))
+}
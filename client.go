@@ -2,13 +2,23 @@ package gowebdav
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/patrickmn/go-cache"
 	"github.com/rickb777/gowebdav/auth"
+	"github.com/spf13/afero"
 	"io"
+	"io/ioutil"
+	"mime"
 	"net/http"
 	"net/url"
 	"os"
 	pathpkg "path"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -18,11 +28,15 @@ import (
 // (https://tools.ietf.org/html/rfc7230#section-3.1.2)
 const responseStatusOK = " 200 "
 
+// DepthInfinity is the depth that selects all members of a collection, recursively.
+const DepthInfinity = -1
+
 const (
-	MethodMove     = "MOVE"
-	MethodCopy     = "COPY"
-	MethodMkcol    = "MKCOL"
-	MethodPropfind = "PROPFIND"
+	MethodMove      = "MOVE"
+	MethodCopy      = "COPY"
+	MethodMkcol     = "MKCOL"
+	MethodPropfind  = "PROPFIND"
+	MethodProppatch = "PROPPATCH"
 )
 
 type HttpClient interface {
@@ -35,45 +49,199 @@ type Client interface {
 	// Ping tests the connection to the webdav server.
 	Ping() error
 
+	// PingPath tests the connection to the webdav server using a given path.
+	PingPath(path string) error
+
+	// ServerTime gets the server's clock time from the Date response header.
+	ServerTime() (time.Time, error)
+
+	// ClockSkew estimates how far the server's clock is ahead of the local clock.
+	ClockSkew() (time.Duration, error)
+
 	//----- Webdav methods -----
 
+	// Capabilities reports the WebDAV compliance classes and the HTTP methods that
+	// the server supports for path.
+	Capabilities(path string) (davClasses []string, allowedMethods []string, err error)
+
 	// ReadDir reads the contents of a remote directory
 	ReadDir(path string) ([]os.FileInfo, error)
 
+	// ReadDirInfinite lists the whole tree below a remote directory using a single
+	// Depth: infinity PROPFIND, which not all servers allow.
+	ReadDirInfinite(path string) ([]os.FileInfo, error)
+
+	// ReadDirFunc reads the contents of a remote directory, calling fn for each
+	// entry as it is received. Reading stops if fn returns an error.
+	ReadDirFunc(path string, fn func(os.FileInfo) error) error
+
+	// PropFind fetches the named properties of the resource at path and, depending on
+	// depth, its members. The result maps each href to that resource's property values.
+	PropFind(path string, depth int, props []xml.Name) (map[string]map[xml.Name]string, error)
+
+	// GetProperty fetches a single property of the resource at path, reporting
+	// whether the server has it.
+	GetProperty(path string, name xml.Name) (value string, found bool, err error)
+
+	// PropFindRaw sends a PROPFIND with the given request body and returns the
+	// multistatus response body unparsed.
+	PropFindRaw(path string, depth int, body string) ([]byte, error)
+
+	// InvalidateCache removes path from the cache enabled by EnableStatCache.
+	InvalidateCache(path string)
+
+	// Exists reports whether a resource exists at path, using a lightweight HEAD request.
+	Exists(path string) (bool, error)
+
+	// Head gets the size, content type, ETag and modification time of a resource
+	// from the headers of a HEAD request.
+	Head(path string) (size int64, contentType string, etag string, modTime time.Time, err error)
+
+	// Checksums gets the checksums that the server has recorded for a file, keyed
+	// by algorithm. Only some servers, such as ownCloud and Nextcloud, provide these.
+	Checksums(path string) (map[string]string, error)
+
+	// VerifyDownload downloads a file and checks it against the expected checksum.
+	VerifyDownload(path string, expected string, algo string) error
+
+	// Quota returns the number of bytes used and available in the collection at path.
+	// Available may be QuotaUnlimited or QuotaUnknown.
+	Quota(path string) (used, available int64, err error)
+
+	// Walk walks the remote file tree rooted at root, calling fn for each file or
+	// collection in the tree, including root, in lexical order.
+	Walk(root string, fn filepath.WalkFunc) error
+
+	// Glob returns the paths of all remote files and collections matching pattern.
+	// As well as the path.Match syntax, a "**" segment matches zero or more collections.
+	Glob(pattern string) ([]string, error)
+
+	// GlobInfo is like Glob but returns the file information of the matches.
+	GlobInfo(pattern string) ([]os.FileInfo, error)
+
 	// Copy copies a file from oldpath to newpath.
-	// If newpath already exists and is not a directory, Copy overwrites it.
-	Copy(oldpath, newpath string) error
+	// If newpath already exists, Copy overwrites it.
+	Copy(oldpath, newpath string, opts ...OpOpt) error
 
 	// CopyWithoutOverwriting copies a file from oldpath to newpath.
-	CopyWithoutOverwriting(oldpath, newpath string) error
+	CopyWithoutOverwriting(oldpath, newpath string, opts ...OpOpt) error
+
+	// CopyDepth copies oldpath to newpath with an explicit depth, either "infinity"
+	// or "0"; the latter copies a collection without its members.
+	CopyDepth(oldpath, newpath string, depth string, overwrite bool, opts ...OpOpt) error
+
+	// CopyTo copies oldpath to destURL, which is an absolute URL, possibly on
+	// another server.
+	CopyTo(oldpath string, destURL string, overwrite bool) error
+
+	// MoveTo moves oldpath to destURL, which is an absolute URL, possibly on
+	// another server.
+	MoveTo(oldpath string, destURL string, overwrite bool) error
 
 	// ReadFile reads the contents of a remote file.
-	ReadFile(path string) ([]byte, error)
+	ReadFile(path string, opts ...OpOpt) ([]byte, error)
 
 	// ReadStream reads the stream for a given path. The caller must
 	// close the returned io.ReadCloser.
-	ReadStream(path string) (io.ReadCloser, error)
+	ReadStream(path string, opts ...OpOpt) (io.ReadCloser, error)
+
+	// ReadStreamWithResponse is like ReadStream but also returns the HTTP response,
+	// so that its status and headers can be inspected, even when there is an error.
+	ReadStreamWithResponse(path string, opts ...OpOpt) (io.ReadCloser, *http.Response, error)
+
+	// ReadStreamWithProgress is like ReadStream but calls fn periodically
+	// with the number of bytes read so far.
+	ReadStreamWithProgress(path string, fn func(bytesSoFar int64)) (io.ReadCloser, error)
+
+	// ReadStreamIfModifiedSince reads the stream for a given path, provided that it
+	// has been modified since the given time. Otherwise, the error wraps ErrNotModified.
+	ReadStreamIfModifiedSince(path string, since time.Time) (io.ReadCloser, error)
+
+	// Touch creates an empty file if path does not exist. Otherwise, it updates
+	// the modification time, where the server supports that.
+	Touch(path string) error
 
 	// WriteFile writes data to a given path on the webdav server.
-	WriteFile(path string, data []byte, _ os.FileMode) error
+	WriteFile(path string, data []byte, _ os.FileMode, opts ...OpOpt) error
+
+	// WriteFileAtomic writes data to path via a temporary file that is then moved
+	// into place, so that readers never see a partly written file.
+	WriteFileAtomic(path string, data []byte, ct string) error
 
 	// WriteStream writes from a stream to a resource on the webdav server.
-	WriteStream(path string, stream io.Reader, _ os.FileMode) error
+	WriteStream(path string, stream io.Reader, _ os.FileMode, opts ...OpOpt) error
+
+	// GetToFile downloads a remote file to a local file, without holding it in memory.
+	GetToFile(remotePath, localPath string, perm os.FileMode) error
+
+	// PutFromFile uploads a local file to a remote file, without holding it in memory.
+	PutFromFile(remotePath, localPath string, opts ...OpOpt) error
+
+	// GetDirectory downloads a remote directory tree to the local directory,
+	// preserving the modification times of files.
+	GetDirectory(remoteDir, localDir string, opts SyncOptions) error
+
+	// PutDirectory uploads a local directory tree to the remote directory, creating
+	// collections as needed.
+	PutDirectory(localDir, remoteDir string, opts SyncOptions) error
+
+	// DownloadTar writes the remote directory tree at remoteDir to w as a tar archive.
+	DownloadTar(remoteDir string, w io.Writer) error
+
+	// DownloadZip writes the remote directory tree at remoteDir to w as a zip archive.
+	DownloadZip(remoteDir string, w io.Writer) error
+
+	// UploadTar expands the tar archive read from r onto the server below remoteDir.
+	UploadTar(remoteDir string, r io.Reader) error
+
+	// ChunkedUpload uploads a large file to a Nextcloud or ownCloud server in
+	// chunks of chunkSize bytes, using their chunked upload protocol.
+	ChunkedUpload(path string, stream io.Reader, totalSize int64, chunkSize int64) error
+
+	// WriteStreamSized writes size bytes from a stream to a resource on the webdav
+	// server, sending a Content-Length header instead of using chunked encoding.
+	WriteStreamSized(path string, stream io.Reader, size int64, ct string, opts ...OpOpt) error
+
+	// WriteStreamAt overwrites part of an existing file, starting at offset, with
+	// length bytes from a stream. Only some servers, such as sabre/dav, support this.
+	WriteStreamAt(path string, stream io.Reader, offset int64, length int64) error
+
+	// ResumableUpload uploads size bytes read from r to path, sending only the part
+	// that the server does not already have, where it supports ranged PUT.
+	ResumableUpload(path string, r io.ReaderAt, size int64) error
+
+	// WriteStreamIfMatch writes from a stream only if the resource's current ETag
+	// matches etag. Otherwise, the error wraps ErrPreconditionFailed.
+	WriteStreamIfMatch(path string, stream io.Reader, _ os.FileMode, etag string) error
+
+	// CreateExclusive writes from a stream only if the resource does not already
+	// exist. Otherwise, the error wraps ErrPreconditionFailed.
+	CreateExclusive(path string, stream io.Reader, _ os.FileMode) error
+
+	// WriteStreamWithProgress is like WriteStream but calls fn periodically
+	// with the number of bytes sent so far.
+	WriteStreamWithProgress(path string, stream io.Reader, _ os.FileMode, fn func(bytesSoFar int64), opts ...OpOpt) error
 
 	//----- Afero.Fs methods below (incomplete) -----
 
 	// Create creates a file in the filesystem, returning the file and an
 	// error, if any happens.
-	// Create(name string) (File, error)
+	Create(name string) (afero.File, error)
+
+	// Mkdir makes a directory (also known as a collection in Webdav).
+	// If the directory already exists, the error satisfies os.IsExist.
+	Mkdir(path string, perm os.FileMode, opts ...OpOpt) error
 
-	// Mkdir makes a directory (also known as a collection in Webdav)
-	Mkdir(path string, perm os.FileMode) error
+	// CreateCollection makes a collection, reporting whether it was created or
+	// already existed.
+	CreateCollection(path string) (created bool, err error)
 
 	// MkdirAll creates a directory path and all parents that do not exist yet.
+	// Unlike Mkdir, it succeeds if the directory already exists.
 	MkdirAll(path string, perm os.FileMode) error
 
 	// Open opens a file for reading.
-	// Open(name string) (File, error)
+	Open(name string) (afero.File, error)
 
 	// OpenFile is the generalized open call; most users will use Open
 	// or Create instead. It opens the named file with specified flag
@@ -81,22 +249,35 @@ type Client interface {
 	// is passed, it is created with mode perm (before umask). If successful,
 	// methods on the returned File can be used for I/O.
 	// If there is an error, it will be of type *PathError.
-	// OpenFile(name string, flag int, perm os.FileMode) (File, error)
+	OpenFile(name string, flag int, perm os.FileMode) (afero.File, error)
 
 	// Remove removes a remote file
-	Remove(path string) error
+	Remove(path string, opts ...OpOpt) error
 
 	// RemoveAll removes remote files
-	RemoveAll(path string) error
+	RemoveAll(path string, opts ...OpOpt) error
+
+	// RemoveDir removes a remote collection, with its members if recursive is true.
+	RemoveDir(path string, recursive bool) error
+
+	// RemoveMany removes each of the remote paths, concurrently, continuing after
+	// failures. The error lists every path that could not be removed.
+	RemoveMany(paths []string) error
 
 	// Rename renames (moves) oldpath to newpath.
-	// If newpath already exists and is not a directory, Rename replaces it.
-	Rename(oldname, newname string) error
+	// If newpath already exists, Rename replaces it.
+	Rename(oldname, newname string, opts ...OpOpt) error
+
+	// MoveCreate is like Rename, but first creates the parent collections of newpath.
+	MoveCreate(oldpath, newpath string, opts ...OpOpt) error
+
+	// CopyCreate is like Copy, but first creates the parent collections of newpath.
+	CopyCreate(oldpath, newpath string, opts ...OpOpt) error
 
 	// RenameWithoutOverwriting renames (moves) oldpath to newpath.
 	// If newpath already exists, a *os.PathError error is returned
 	// containing the message "file already exists".
-	RenameWithoutOverwriting(oldpath, newpath string) error
+	RenameWithoutOverwriting(oldpath, newpath string, opts ...OpOpt) error
 
 	// Stat returns a FileInfo describing the named file, or an error, if any happens.
 	Stat(path string) (os.FileInfo, error)
@@ -104,39 +285,122 @@ type Client interface {
 	// The name of this FileSystem.
 	Name() string
 
+	// AddLockToken records a lock token held for path, which is then submitted with
+	// every mutating operation on path or its members.
+	AddLockToken(path, token string)
+
+	// RemoveLockToken forgets the lock token recorded for path.
+	RemoveLockToken(path string)
+
+	// RefreshLock refreshes a lock held with token on path, requesting a new
+	// timeout in seconds, and returns the timeout granted.
+	RefreshLock(path string, token string, timeoutSeconds int) (int, error)
+
+	// ListLocks lists the locks that are active on path.
+	ListLocks(path string) ([]LockInfo, error)
+
+	// URL gets the absolute URL of path, as used in requests.
+	URL(path string) string
+
+	// BasePath gets the path at which the WebDAV tree is mounted on the server,
+	// without any trailing slash. It is empty if the tree is at the root of the host.
+	BasePath() string
+
 	// Chmod changes the mode of the named file to mode.
 	//Chmod(name string, mode os.FileMode) error
 
 	// Chown changes the uid and gid of the named file.
 	//Chown(name string, uid, gid int) error
 
-	//Chtimes changes the access and modification times of the named file
-	//Chtimes(name string, atime time.Time, mtime time.Time) error
+	// Chtimes changes the access and modification times of the named file.
+	// Not all servers support this.
+	Chtimes(name string, atime time.Time, mtime time.Time) error
 }
 
 // client defines our structure
 type client struct {
-	root    string
-	headers http.Header
-	hc      HttpClient
+	rootMutex sync.Mutex
+	root      string
 
 	authMutex sync.Mutex
 	auth      auth.Authenticator
+
+	lockMutex  sync.Mutex
+	lockTokens map[string]string
+
+	statCache *cache.Cache
+
+	clientConfig
+}
+
+// clientConfig holds the settings made by the ClientOpt options, which are copied
+// to any client derived from this one.
+type clientConfig struct {
+	headers        http.Header
+	hc             HttpClient
+	transportOpts  []func(*http.Transport)
+	retry          retryPolicy
+	compression    bool
+	noFollow       bool
+	updateRoot     bool
+	cookieJar      bool
+	timeout        time.Duration
+	preferMinimal  bool
+	noParents      bool
+	fileProps      string
+	propNames      []xml.Name
+	davPrefix      string
+	userAgent      string
+	expectContinue bool
+	logger         func(string)
+	observer       func(op string, path string, status int, dur time.Duration, err error)
+	headFallback   bool
+
+	batchConcurrency int
+	limiter          chan struct{}
 }
 
 //-------------------------------------------------------------------------------------------------
 
 // NewClient creates a new Client. By default, this uses the default HTTP client.
+//
+// The uri is the root of the WebDAV tree, such as https://example.com/dav. If it
+// has no scheme, https is assumed. Any trailing slash is removed. NewClient panics
+// if uri is not a valid http or https URL, or if it has a query or fragment, which
+// would make every request URL malformed.
 func NewClient(uri string, opts ...ClientOpt) Client {
+	root, err := normaliseRoot(uri)
+	if err != nil {
+		panic("gowebdav: " + err.Error())
+	}
+
 	cl := &client{
-		root:    withoutTrailingSlash(uri),
-		headers: make(http.Header),
-		hc:      http.DefaultClient,
-		auth:    auth.Anonymous,
+		root: root,
+		auth: auth.Anonymous,
+		clientConfig: clientConfig{
+			headers:   make(http.Header),
+			hc:        http.DefaultClient,
+			logger:    func(string) {},
+			davPrefix: "d",
+		},
 	}
 	for _, opt := range opts {
 		opt(cl)
 	}
+	if len(cl.transportOpts) > 0 {
+		cl.hc = withTransport(cl.hc, cl.transportOpts)
+	}
+	if cl.noFollow || cl.updateRoot {
+		cl.hc = withoutRedirects(cl.hc)
+	} else {
+		cl.hc = withoutMethodRedirects(cl.hc)
+	}
+	if cl.cookieJar {
+		cl.hc = withCookieJar(cl.hc)
+	}
+	if cl.timeout > 0 {
+		cl.hc = withTimeout(cl.hc, cl.timeout)
+	}
 	return cl
 }
 
@@ -151,15 +415,104 @@ func AddHeader(key, value string) ClientOpt {
 	}
 }
 
+// SetAcceptLanguage sets the Accept-Language header sent with every request, such
+// as "en-US". Some servers localise display names and error messages according to
+// this, so setting it gives consistent results whatever the server's default locale.
+func SetAcceptLanguage(tag string) ClientOpt {
+	return func(c Client) {
+		c.(*client).headers.Set("Accept-Language", tag)
+	}
+}
+
+// SetUserAgent sets the User-Agent header sent with every request, replacing any
+// set by AddHeader. By default, this is "gowebdav" followed by the module version,
+// if it is known, e.g. "gowebdav/v1.2.3", rather than Go's default.
+func SetUserAgent(ua string) ClientOpt {
+	return func(c Client) {
+		c.(*client).userAgent = ua
+	}
+}
+
+// SetPreferMinimal sends "Prefer: return=minimal" (RFC 8144) with write operations,
+// i.e. PUT, COPY, MOVE, MKCOL, DELETE and PROPPATCH. This asks the server to omit
+// response bodies that are not needed, such as the multistatus body of a successful
+// PROPPATCH. The responses are handled in the same way whether or not the server
+// applies the preference.
+func SetPreferMinimal() ClientOpt {
+	return func(c Client) {
+		c.(*client).preferMinimal = true
+	}
+}
+
+// SetAutoCreateParents controls whether missing parent collections are created
+// when writing, copying or moving a resource. It is enabled by default. When
+// disabled, a missing parent makes the operation fail with a *StatusError, usually
+// with 409 Conflict.
+func SetAutoCreateParents(enabled bool) ClientOpt {
+	return func(c Client) {
+		c.(*client).noParents = !enabled
+	}
+}
+
+// SetPropfindProperties replaces the PROPFIND request body that Stat, ReadDir,
+// ReadDirInfinite and Walk send, which is a propfind element listing the DAV:
+// properties that they use. This allows other properties to be requested, such as
+// those in the urn:schemas-microsoft-com: namespace used by SharePoint. Properties
+// that are not DAV: properties are available from the Props method of the returned
+// os.FileInfo values, e.g.
+//
+//	props := fi.(interface{ Props() map[xml.Name]string }).Props()
+//
+// The body should still list the DAV: properties, otherwise sizes, times and so on
+// will be missing. AddPropfindProperties is usually easier.
+func SetPropfindProperties(body string) ClientOpt {
+	return func(c Client) {
+		c.(*client).fileProps = body
+	}
+}
+
+// AddPropfindProperties adds the named properties to those that Stat, ReadDir,
+// ReadDirInfinite and Walk request. See SetPropfindProperties.
+func AddPropfindProperties(names ...xml.Name) ClientOpt {
+	return func(c Client) {
+		c.(*client).propNames = append(c.(*client).propNames, names...)
+	}
+}
+
+// SetDAVPrefix sets the namespace prefix used for DAV: elements in the request
+// bodies that the client generates. The default is "d", which suits almost all
+// servers; a few accept only "D". An empty prefix makes DAV: the default namespace.
+func SetDAVPrefix(prefix string) ClientOpt {
+	return func(c Client) {
+		c.(*client).davPrefix = prefix
+	}
+}
+
 // SetAuthentication sets the authentication credentials and method.
-// Leave the authenticator method blank to allow HTTP challenges to
-// select an appropriate method. Otherwise it should be "basic".
+// Use auth.Deferred to allow HTTP challenges to select an appropriate
+// method. Otherwise use a preemptive authenticator such as auth.Basic.
+// With auth.Deferred, only the first request is challenged: the method it
+// negotiates is kept and used preemptively for all later requests.
+//
+// With auth.Deferred (and the default anonymous access), the body of each
+// upload is buffered in memory so that it can be sent again after a 401
+// challenge, unless it is an io.Seeker, such as an *os.File, in which case it
+// is re-read from where it started. Preemptive authenticators do not need
+// either, so they are better for large streamed uploads.
 func SetAuthentication(authenticator auth.Authenticator) ClientOpt {
 	return func(c Client) {
 		c.(*client).auth = authenticator
 	}
 }
 
+// SetLogger sets a function that receives the client's diagnostic messages, such
+// as when a request is retried. By default, these messages are discarded.
+func SetLogger(logger func(string)) ClientOpt {
+	return func(c Client) {
+		c.(*client).logger = logger
+	}
+}
+
 // SetHttpClient changes the http.Client. This allows control over
 // the http.Transport, timeouts etc.
 func SetHttpClient(httpClient HttpClient) ClientOpt {
@@ -168,14 +521,163 @@ func SetHttpClient(httpClient HttpClient) ClientOpt {
 	}
 }
 
+// SetHTTP2 enables or disables HTTP/2 on a clone of the client's transport. Enabling it
+// allows many small requests to be multiplexed over one connection, which helps bulk
+// transfers. Some WebDAV servers behave badly over HTTP/2, hence the option to disable it.
+//
+// Like SetMaxIdleConnsPerHost, this works when the HttpClient is an *http.Client using
+// an *http.Transport (including the defaults); other HttpClients are left unchanged.
+func SetHTTP2(enabled bool) ClientOpt {
+	return func(c Client) {
+		c.(*client).transportOpts = append(c.(*client).transportOpts, func(t *http.Transport) {
+			t.ForceAttemptHTTP2 = enabled
+			if !enabled {
+				// a non-nil, empty map prevents HTTP/2 being negotiated
+				t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+				if t.TLSClientConfig != nil {
+					t.TLSClientConfig = t.TLSClientConfig.Clone()
+					t.TLSClientConfig.NextProtos = withoutString(t.TLSClientConfig.NextProtos, "h2")
+				}
+			}
+		})
+	}
+}
+
+// SetMaxIdleConnsPerHost sets the number of idle connections kept open for reuse on a
+// clone of the client's transport. The default in net/http is only 2, which is small
+// for workloads that transfer many small files.
+func SetMaxIdleConnsPerHost(n int) ClientOpt {
+	return func(c Client) {
+		c.(*client).transportOpts = append(c.(*client).transportOpts, func(t *http.Transport) {
+			t.MaxIdleConnsPerHost = n
+			if t.MaxIdleConns > 0 && t.MaxIdleConns < n {
+				t.MaxIdleConns = n
+			}
+		})
+	}
+}
+
+// SetClientCertificate presents cert to servers that require mutual TLS.
+//
+// Like SetRootCAs, this is applied to a clone of the client's transport, so it also
+// adjusts an *http.Client supplied via SetHttpClient, whatever the order of the
+// options. It is ignored for other HttpClients, which must be configured directly.
+func SetClientCertificate(cert tls.Certificate) ClientOpt {
+	return func(c Client) {
+		c.(*client).transportOpts = append(c.(*client).transportOpts, func(t *http.Transport) {
+			t.TLSClientConfig = cloneTLSConfig(t.TLSClientConfig)
+			t.TLSClientConfig.Certificates = append(t.TLSClientConfig.Certificates, cert)
+		})
+	}
+}
+
+// SetRootCAs sets the certificate authorities used to verify the server's certificate,
+// instead of the host's root CA set. See SetClientCertificate for when this applies.
+func SetRootCAs(pool *x509.CertPool) ClientOpt {
+	return func(c Client) {
+		c.(*client).transportOpts = append(c.(*client).transportOpts, func(t *http.Transport) {
+			t.TLSClientConfig = cloneTLSConfig(t.TLSClientConfig)
+			t.TLSClientConfig.RootCAs = pool
+		})
+	}
+}
+
+// EnableCookieJar keeps the cookies set by the server and sends them with later
+// requests. Some gateways, particularly SSO-fronted ones, issue a session cookie
+// after the first authenticated request and expect it thereafter; without it, every
+// request has to authenticate again. This is also useful with auth.SAML, because
+// SharePoint sets its FedAuth and rtFa session cookies in this way.
+//
+// The jar is installed on a copy of the *http.Client, including one supplied via
+// SetHttpClient, unless that client already has a jar of its own. It is ignored for
+// other HttpClients.
+func EnableCookieJar() ClientOpt {
+	return func(c Client) {
+		c.(*client).cookieJar = true
+	}
+}
+
+func cloneTLSConfig(cfg *tls.Config) *tls.Config {
+	if cfg == nil {
+		return &tls.Config{}
+	}
+	return cfg.Clone()
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// OpOpt is an option that applies to a single operation, unlike ClientOpt, which
+// applies to every operation of the client.
+type OpOpt func(*http.Request)
+
+// WithIfMatch makes a mutating operation conditional on the resource's
+// current ETag matching etag.
+func WithIfMatch(etag string) OpOpt {
+	return WithPrecondition("If-Match", quoteETag(etag))
+}
+
+// WithIfNoneMatch makes a mutating operation conditional on the resource's
+// current ETag not matching etag. Use "*" to require that the resource does
+// not exist.
+func WithIfNoneMatch(etag string) OpOpt {
+	if etag != "*" {
+		etag = quoteETag(etag)
+	}
+	return WithPrecondition("If-None-Match", etag)
+}
+
+// WithPrecondition sets an arbitrary conditional header on a mutating operation,
+// for example "If-Schedule-Tag-Match" for CalDAV scheduling. If the server then
+// responds 412 Precondition Failed, the returned error wraps ErrPreconditionFailed.
+func WithPrecondition(headerName, value string) OpOpt {
+	return func(rq *http.Request) {
+		rq.Header.Set(headerName, value)
+	}
+}
+
+// WithHeaders sets headers on a single operation. They replace any headers of
+// the same name set on the client with AddHeader.
+func WithHeaders(h http.Header) OpOpt {
+	return func(rq *http.Request) {
+		for k, vals := range h {
+			rq.Header.Del(k)
+			for _, v := range vals {
+				rq.Header.Add(k, v)
+			}
+		}
+	}
+}
+
+// WithContext sends the request with ctx, so that it is abandoned if ctx is
+// cancelled or its deadline passes. For ReadStream, this also applies while the
+// response body is being read.
+func WithContext(ctx context.Context) OpOpt {
+	return func(rq *http.Request) {
+		*rq = *rq.WithContext(ctx)
+	}
+}
+
 //-------------------------------------------------------------------------------------------------
 
 func (c *client) Name() string {
-	return "webdav:" + c.root
+	return "webdav:" + c.getRoot()
 }
 
+// Ping checks that the server can be reached, by sending OPTIONS for the root.
+// This is the same as PingPath("/").
 func (c *client) Ping() error {
-	rs, err := c.options("/")
+	return c.PingPath("/")
+}
+
+// PingPath checks that the server can be reached, by sending OPTIONS for path,
+// which is relative to the root like all other paths. This helps when the root
+// itself is not a resource that the server will answer for.
+//
+// Any 2xx response succeeds, as does any other response with a DAV header, which
+// proves that the server is a WebDAV endpoint. An authentication challenge (401) is
+// answered in the usual way, so a 401 error means the credentials were rejected.
+func (c *client) PingPath(path string) error {
+	rs, err := c.options("Ping", path)
 	if err != nil {
 		return err
 	}
@@ -185,21 +687,45 @@ func (c *client) Ping() error {
 		return err
 	}
 
-	if rs.StatusCode != http.StatusOK {
-		return newPathError("Connect", c.root, rs.StatusCode)
+	ok := rs.StatusCode >= 200 && rs.StatusCode <= 299
+	if !ok && rs.Header.Get("DAV") == "" {
+		return newPathError("Connect", c.resourceURL(path), rs.StatusCode)
 	}
 
 	return nil
 }
 
+// Capabilities reports the WebDAV compliance classes (e.g. "1", "2", "3") and the
+// HTTP methods that the server supports for path, from the DAV and Allow headers
+// of an OPTIONS response. Class 2 indicates that locking is supported.
+func (c *client) Capabilities(path string) (davClasses []string, allowedMethods []string, err error) {
+	rs, err := c.options("Capabilities", path)
+	if err != nil {
+		return nil, nil, newPathErrorErr("Capabilities", path, err)
+	}
+	rs.Body.Close()
+
+	if rs.StatusCode != http.StatusOK && rs.StatusCode != http.StatusNoContent {
+		return nil, nil, newPathError("Capabilities", path, rs.StatusCode)
+	}
+
+	return splitHeaderList(rs.Header.Values("DAV")), splitHeaderList(rs.Header.Values("Allow")), nil
+}
+
 type props struct {
-	Status      string   `xml:"DAV: status"`
-	Name        string   `xml:"DAV: prop>displayname,omitempty"`
-	Type        xml.Name `xml:"DAV: prop>resourcetype>collection,omitempty"`
-	Size        string   `xml:"DAV: prop>getcontentlength,omitempty"`
-	ContentType string   `xml:"DAV: prop>getcontenttype,omitempty"`
-	ETag        string   `xml:"DAV: prop>getetag,omitempty"`
-	Modified    string   `xml:"DAV: prop>getlastmodified,omitempty"`
+	Status string `xml:"DAV: status"`
+	Prop   prop   `xml:"DAV: prop"`
+}
+
+type prop struct {
+	Name        string    `xml:"DAV: displayname,omitempty"`
+	Type        xml.Name  `xml:"DAV: resourcetype>collection,omitempty"`
+	Size        string    `xml:"DAV: getcontentlength,omitempty"`
+	ContentType string    `xml:"DAV: getcontenttype,omitempty"`
+	ETag        string    `xml:"DAV: getetag,omitempty"`
+	Modified    string    `xml:"DAV: getlastmodified,omitempty"`
+	Created     string    `xml:"DAV: creationdate,omitempty"`
+	Extra       []RawProp `xml:",any"`
 }
 
 type response struct {
@@ -207,100 +733,236 @@ type response struct {
 	Props []props `xml:"DAV: propstat"`
 }
 
-func getProps(r *response, status string) *props {
-	for _, prop := range r.Props {
-		if strings.Contains(prop.Status, status) {
-			return &prop
+func getProps(r *response, status string) *prop {
+	for i := range r.Props {
+		if strings.Contains(r.Props[i].Status, status) {
+			return &r.Props[i].Prop
 		}
 	}
 	return nil
 }
 
-// ReadDir reads the contents of a remote directory
+// newFileinfo sets the fields of a fileinfo that come directly from the properties.
+// The caller sets its name and path, and its size unless it is a collection.
+func newFileinfo(p *prop) fileinfo {
+	return fileinfo{
+		contentType: p.ContentType,
+		modified:    parseModified(&p.Modified),
+		created:     parseCreated(&p.Created),
+		etag:        p.ETag,
+		props:       extraProps(p.Extra),
+	}
+}
+
+// ReadDir reads the contents of a remote directory. If path is not a collection,
+// the error wraps ErrNotDirectory.
 func (c *client) ReadDir(path string) ([]os.FileInfo, error) {
-	path = withSurroundingSlashes(path)
+	_, files, err := c.readDir(path)
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// ReadDirFunc reads the contents of a remote directory, calling fn for each entry
+// as soon as it has been parsed from the server's response. Unlike ReadDir, the
+// entries are not held in memory, so this suits very large directories. If fn
+// returns an error, reading stops and that error is returned.
+func (c *client) ReadDirFunc(path string, fn func(os.FileInfo) error) error {
+	_, err := c.readDirFunc(path, fn)
+	return err
+}
+
+// readDir reads the contents of a remote directory, also returning the href
+// that the server reported for the directory itself.
+func (c *client) readDir(path string) (string, []os.FileInfo, error) {
 	files := make([]os.FileInfo, 0)
+	selfHref, err := c.readDirFunc(path, func(fi os.FileInfo) error {
+		files = append(files, fi)
+		return nil
+	})
+	return selfHref, files, err
+}
+
+// readDirFunc streams the contents of a remote directory to fn, returning the
+// href that the server reported for the directory itself.
+func (c *client) readDirFunc(path string, fn func(os.FileInfo) error) (string, error) {
+	path = withSurroundingSlashes(path)
+	var fnErr error
 	skipSelf := true
+	selfHref := ""
 	parse := func(resp interface{}) error {
 		r := resp.(*response)
 
 		if skipSelf {
 			skipSelf = false
+			selfHref = r.Href
 			if p := getProps(r, responseStatusOK); p != nil && p.Type.Local == "collection" {
 				r.Props = nil
 				return nil
 			}
-			return newPathError("ReadDir", path, 405)
+			return newPathErrorErr("ReadDir", path, ErrNotDirectory)
 		}
 
 		if p := getProps(r, responseStatusOK); p != nil {
-			fi := fileinfo{
-				contentType: p.ContentType,
-				modified:    parseModified(&p.Modified),
-				etag:        p.ETag,
-			}
-			if ps, err := url.PathUnescape(r.Href); err == nil {
-				fi.name = pathpkg.Base(ps)
+			fi := newFileinfo(p)
+			if rel, ok := hrefUnder(selfHref, r.Href); ok {
+				fi.name = pathpkg.Base(rel)
+				fi.path = path + rel
 			} else {
-				fi.name = p.Name
+				if ps, err := url.PathUnescape(r.Href); err == nil {
+					fi.name = pathpkg.Base(ps)
+				} else {
+					fi.name = p.Name
+				}
+				fi.path = path + fi.name
 			}
-			fi.path = path + fi.name
 
 			if p.Type.Local == "collection" {
 				fi.path += "/"
 				fi.isdir = true
 			} else {
-				fi.size = parseInt64(&p.Size)
+				fi.size, fi.hasSize = parseSize(&p.Size)
 			}
 
-			files = append(files, fi)
+			if fnErr = fn(fi); fnErr != nil {
+				return fnErr
+			}
 		}
 
 		r.Props = nil
 		return nil
 	}
 
-	err := c.propfind(path, false, requiredProperties, &response{}, parse)
+	err := c.propfind("ReadDir", path, 1, c.fileProperties(), &response{}, parse)
+
+	if fnErr != nil {
+		return selfHref, fnErr
+	}
+	if err != nil {
+		err = withOp("ReadDir", path, err)
+	}
+	return selfHref, err
+}
+
+// fileProperties gets the PROPFIND body used by Stat, ReadDir and ReadDirInfinite.
+func (c *client) fileProperties() string {
+	if c.fileProps != "" {
+		return c.fileProps
+	}
+	all := append(append([]xml.Name{}, requiredNames...), c.propNames...)
+	return propfindBody(c.davPrefix, all)
+}
+
+// requiredNames are the properties that Stat, ReadDir and ReadDirInfinite use.
+var requiredNames = []xml.Name{
+	{Space: "DAV:", Local: "displayname"},
+	{Space: "DAV:", Local: "resourcetype"},
+	{Space: "DAV:", Local: "getcontentlength"},
+	{Space: "DAV:", Local: "getcontenttype"},
+	{Space: "DAV:", Local: "getetag"},
+	{Space: "DAV:", Local: "getlastmodified"},
+	{Space: "DAV:", Local: "creationdate"},
+}
+
+// PropFind fetches the named properties of the resource at path, including
+// server-specific properties such as {http://owncloud.org/ns}fileid. The depth
+// is 0 for the resource only, 1 to include its members, or DepthInfinity. If no
+// property names are given, all properties are requested.
+//
+// The result maps each href returned by the server to the values of the properties
+// that were found for it. Properties with nested XML content are returned as the
+// raw inner XML; others as their text.
+func (c *client) PropFind(path string, depth int, props []xml.Name) (map[string]map[xml.Name]string, error) {
+	results := make(map[string]map[xml.Name]string)
+	parse := func(resp interface{}) error {
+		r := resp.(*anyResponse)
+		values := make(map[xml.Name]string)
+		for _, ps := range r.Propstats {
+			if strings.Contains(ps.Status, responseStatusOK) {
+				for _, p := range ps.Prop.Values {
+					values[p.XMLName] = p.value()
+				}
+			}
+		}
+		results[r.Href] = values
+
+		r.Propstats = nil
+		return nil
+	}
+
+	err := c.propfind("PropFind", path, depth, propfindBody(c.davPrefix, props), &anyResponse{}, parse)
+
+	if err != nil {
+		err = withOp("PropFind", path, err)
+		return nil, err
+	}
+	return results, nil
+}
 
+// GetProperty fetches a single property of the resource at path. If the server
+// does not have the property, for example because it is reported in a 404 propstat,
+// found is false. Errors are only returned when the PROPFIND itself fails.
+func (c *client) GetProperty(path string, name xml.Name) (value string, found bool, err error) {
+	results, err := c.PropFind(path, 0, []xml.Name{name})
 	if err != nil {
-		if _, ok := err.(*os.PathError); !ok {
-			err = newPathErrorErr("ReadDir", path, err)
+		return "", false, err
+	}
+
+	for _, values := range results {
+		if value, found = values[name]; found {
+			return value, true, nil
 		}
 	}
-	return files, err
+	return "", false, nil
 }
 
-const requiredProperties = `<d:propfind xmlns:d='DAV:'>
-			<d:prop>
-				<d:displayname/>
-				<d:resourcetype/>
-				<d:getcontentlength/>
-				<d:getcontenttype/>
-				<d:getetag/>
-				<d:getlastmodified/>
-			</d:prop>
-		</d:propfind>`
+// PropFindRaw sends a PROPFIND with the given request body, such as a propfind
+// element listing the wanted properties, and returns the multistatus response
+// body without parsing it. This helps to diagnose server quirks, and lets callers
+// parse properties that PropFind does not handle. The depth is as for PropFind.
+// Responses other than 207 Multi-Status give an error.
+func (c *client) PropFindRaw(path string, depth int, body string) ([]byte, error) {
+	res, err := c.propfindResponse("PropFindRaw", path, depth, body)
+	if err != nil {
+		return nil, withOp("PropFindRaw", path, err)
+	}
+	defer res.Body.Close()
+
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, newPathErrorErr("PropFindRaw", path, err)
+	}
+	return data, nil
+}
 
 // Stat returns the file stats for a specified path
 func (c *client) Stat(path string) (os.FileInfo, error) {
+	if c.statCache != nil {
+		return c.statConditionally(path)
+	}
+	return c.stat(path)
+}
+
+func (c *client) stat(path string, opts ...OpOpt) (*fileinfo, error) {
 	var fi *fileinfo
 	parse := func(resp interface{}) error {
 		r := resp.(*response)
 		if p := getProps(r, responseStatusOK); p != nil && fi == nil {
-			fi = &fileinfo{
-				name:        p.Name,
-				contentType: p.ContentType,
-				etag:        p.ETag,
+			f := newFileinfo(p)
+			fi = &f
+			if ps, err := url.PathUnescape(r.Href); err == nil {
+				fi.name = pathpkg.Base(ps)
+			} else {
+				fi.name = p.Name
 			}
 
 			if p.Type.Local == "collection" {
 				fi.path = withTrailingSlash(path)
-				fi.modified = time.Unix(0, 0)
 				fi.isdir = true
 			} else {
 				fi.path = path
-				fi.size = parseInt64(&p.Size)
-				fi.modified = parseModified(&p.Modified)
+				fi.size, fi.hasSize = parseSize(&p.Size)
 			}
 		}
 
@@ -308,58 +970,216 @@ func (c *client) Stat(path string) (os.FileInfo, error) {
 		return nil
 	}
 
-	err := c.propfind(path, true, requiredProperties, &response{}, parse)
+	err := c.propfind("Stat", path, 0, c.fileProperties(), &response{}, parse, opts...)
 
 	if err != nil {
-		if _, ok := err.(*os.PathError); !ok {
-			err = newPathErrorErr("Stat", path, err)
+		if c.useHeadFallback(err) {
+			return c.statHead(path)
 		}
+		err = withOp("Stat", path, err)
 	}
 	return fi, err
 }
 
-// Remove removes a remote file
-func (c *client) Remove(path string) error {
-	return c.RemoveAll(path)
+// Chtimes changes the access and modification times of the named file. This
+// uses PROPPATCH to set the Win32LastAccessTime and Win32LastModifiedTime
+// properties in the "urn:schemas-microsoft-com:" namespace, which are used by
+// Windows clients and honoured by IIS and various other servers.
+//
+// Not all servers support this: many store the properties without altering the
+// getlastmodified time reported by Stat. An error is returned if the server
+// rejects the change.
+func (c *client) Chtimes(path string, atime time.Time, mtime time.Time) error {
+	const ms = "urn:schemas-microsoft-com:"
+	ns := newNamespaces(c.davPrefix)
+	prop := ns.element(ms, "Win32LastAccessTime", atime.UTC().Format(http.TimeFormat)) +
+		ns.element(ms, "Win32LastModifiedTime", mtime.UTC().Format(http.TimeFormat))
+	body := ns.wrap("propertyupdate", ns.element("DAV:", "set", ns.element("DAV:", "prop", prop)))
+
+	return c.proppatch("Chtimes", path, body)
 }
 
-// RemoveAll removes remote files
-func (c *client) RemoveAll(path string) error {
-	path = withLeadingSlash(path)
-	rs, err := c.request(http.MethodDelete, path, nil, nil)
+// Exists reports whether a resource exists at path. It uses a HEAD request, which
+// is cheaper than Stat and also works with plain HTTP servers. Status codes other
+// than 2xx and 404 give a *StatusError.
+func (c *client) Exists(path string) (bool, error) {
+	rs, err := c.request("Exists", http.MethodHead, withLeadingSlash(path), nil, nil)
 	if err != nil {
-		return newPathErrorErr("Remove", path, err)
+		return false, newPathErrorErr("Exists", path, err)
 	}
-	err = rs.Body.Close()
+	rs.Body.Close()
+
+	switch {
+	case rs.StatusCode >= 200 && rs.StatusCode <= 299:
+		return true, nil
+	case rs.StatusCode == http.StatusNotFound:
+		return false, nil
+	}
+	return false, newPathError("Exists", path, rs.StatusCode)
+}
+
+// Head gets the size, content type, ETag and modification time of a resource
+// using a HEAD request. Unlike Stat, it does not need PROPFIND, so it works with
+// plain HTTP servers as well as WebDAV ones. The size is -1 if the server does not
+// give a Content-Length; the modification time is the zero time if it does not
+// give a Last-Modified header. Status codes other than 2xx give a *StatusError;
+// for 404, errors.Is(err, os.ErrNotExist) is true.
+func (c *client) Head(path string) (size int64, contentType string, etag string, modTime time.Time, err error) {
+	rs, err := c.request("Head", http.MethodHead, withLeadingSlash(path), nil, nil)
 	if err != nil {
+		return -1, "", "", time.Time{}, newPathErrorErr("Head", path, err)
+	}
+	rs.Body.Close()
+
+	if rs.StatusCode < 200 || rs.StatusCode > 299 {
+		return -1, "", "", time.Time{}, newPathError("Head", path, rs.StatusCode)
+	}
+
+	if lm := rs.Header.Get("Last-Modified"); lm != "" {
+		modTime, _ = http.ParseTime(lm)
+	}
+	return rs.ContentLength, rs.Header.Get("Content-Type"), rs.Header.Get("ETag"), modTime, nil
+}
+
+// Touch creates an empty file at path, creating any missing parent collections.
+// If the resource already exists, its content is left alone; its modification
+// time is set to now using Chtimes, where the server supports that.
+func (c *client) Touch(path string) error {
+	_, err := c.Stat(path)
+	if err == nil {
+		now := time.Now()
+		err = c.Chtimes(path, now, now)
+		if _, rejected := err.(*StatusError); rejected {
+			return nil
+		}
+		return err
+	}
+
+	if !IsNotFound(err) {
 		return err
 	}
 
-	if rs.StatusCode == http.StatusOK || rs.StatusCode == http.StatusNoContent || rs.StatusCode == http.StatusNotFound {
+	// If-None-Match avoids truncating a file created meanwhile by someone else.
+	err = c.WriteStreamSized(path, bytes.NewReader(nil), 0, "", WithIfNoneMatch("*"))
+	if errors.Is(err, ErrPreconditionFailed) {
 		return nil
 	}
+	return err
+}
+
+// Remove removes a remote file
+func (c *client) Remove(path string, opts ...OpOpt) error {
+	return c.RemoveAll(path, opts...)
+}
 
-	return newPathError("Remove", path, rs.StatusCode)
+// RemoveAll removes remote files
+func (c *client) RemoveAll(path string, opts ...OpOpt) error {
+	return c.remove("Remove", path, "", opts...)
 }
 
-// Mkdir makes a directory (also known as a collection in Webdav)
-func (c *client) Mkdir(path string, _ os.FileMode) error {
+// RemoveDir removes the remote collection at path. If recursive is true, its
+// members are removed too and the request has a Depth: infinity header, which
+// some servers require. Otherwise, the collection must be empty; if not, the
+// error wraps ErrNotEmpty. Then the request has a Depth: 0 header, which servers
+// may reject. As for RemoveAll, it is not an error if path does not exist.
+func (c *client) RemoveDir(path string, recursive bool) error {
+	if recursive {
+		return c.remove("RemoveDir", path, "infinity")
+	}
+
+	files, err := c.ReadDir(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil
+	case err != nil:
+		return err
+	case len(files) > 0:
+		return newPathErrorErr("RemoveDir", path, ErrNotEmpty)
+	}
+	return c.remove("RemoveDir", path, "0")
+}
+
+// remove sends DELETE, with a Depth header unless depth is blank.
+func (c *client) remove(op, path, depth string, opts ...OpOpt) error {
+	path = withLeadingSlash(path)
+	c.InvalidateCache(path)
+	rs, err := c.request(op, http.MethodDelete, path, nil, withOpts(func(rq *http.Request) {
+		if depth != "" {
+			rq.Header.Set("Depth", depth)
+		}
+	}, opts))
+	if err != nil {
+		return newPathErrorErr(op, path, err)
+	}
+	defer rs.Body.Close()
+
+	switch rs.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+
+	case http.StatusMultiStatus:
+		// some members of the collection could not be deleted
+		ok, failures, err := c.evaluateMultiStatus(rs.Body)
+		if err != nil {
+			return newPathErrorErr(op, path, err)
+		}
+		if !ok {
+			return &MultiStatusError{Op: op, Path: path, Failures: failures}
+		}
+		return nil
+	}
+
+	return responseError(op, path, rs)
+}
+
+// Mkdir makes a directory (also known as a collection in Webdav).
+// If the directory already exists, the error satisfies os.IsExist.
+func (c *client) Mkdir(path string, _ os.FileMode, opts ...OpOpt) error {
 	path = withSurroundingSlashes(pathpkg.Clean(path))
-	status := c.mkcol(path)
+	status, err := c.mkcol("Mkdir", path, opts...)
+	if err != nil {
+		return err
+	}
 	if status == http.StatusCreated {
 		return nil
 	}
 
+	if c.mkcolExists(path, status) {
+		return newPathErrorErr("Mkdir", path, os.ErrExist)
+	}
+
 	return newPathError("Mkdir", path, status)
 }
 
-// MkdirAll like mkdir -p, but for Webdav
+// CreateCollection makes a collection (directory). It returns true if the
+// collection was created and false if it already existed; neither case is an
+// error. Any other failure, such as a missing parent, gives an error.
+func (c *client) CreateCollection(path string) (created bool, err error) {
+	err = c.Mkdir(path, 0755)
+	if err == nil {
+		return true, nil
+	}
+	if os.IsExist(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+// MkdirAll like mkdir -p, but for Webdav. It succeeds if the directory
+// already exists.
 func (c *client) MkdirAll(path string, _ os.FileMode) error {
 	path = withSurroundingSlashes(pathpkg.Clean(path))
-	status := c.mkcol(path)
-	if status == http.StatusCreated {
+	status, err := c.mkcol("MkdirAll", path)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusCreated || status == http.StatusMethodNotAllowed {
 		return nil
 	} else if status == http.StatusConflict {
+		if c.mkcolExists(path, status) {
+			return nil
+		}
+
 		segments := strings.Split(path, "/")
 		sub := "/"
 		for _, e := range segments {
@@ -367,8 +1187,11 @@ func (c *client) MkdirAll(path string, _ os.FileMode) error {
 				continue
 			}
 			sub += e + "/"
-			status = c.mkcol(sub)
-			if status != http.StatusCreated {
+			status, err = c.mkcol("MkdirAll", sub)
+			if err != nil {
+				return err
+			}
+			if status != http.StatusCreated && !c.mkcolExists(sub, status) {
 				return newPathError("MkdirAll", sub, status)
 			}
 		}
@@ -379,34 +1202,90 @@ func (c *client) MkdirAll(path string, _ os.FileMode) error {
 }
 
 // Rename renames (moves) oldpath to newpath.
-// If newpath already exists and is not a directory, Rename replaces it.
-func (c *client) Rename(oldpath, newpath string) error {
-	return c.copymove(MethodMove, oldpath, newpath, true)
+// If newpath already exists, Rename replaces it. If it is a collection that the
+// server refuses to overwrite, it is deleted and the move is tried again.
+func (c *client) Rename(oldpath, newpath string, opts ...OpOpt) error {
+	return c.copymove("Rename", MethodMove, oldpath, newpath, "infinity", true, opts...)
 }
 
 // RenameWithoutOverwriting renames (moves) oldpath to newpath.
 // If newpath already exists, an error is returned.
-func (c *client) RenameWithoutOverwriting(oldpath, newpath string) error {
-	return c.copymove(MethodMove, oldpath, newpath, false)
+func (c *client) RenameWithoutOverwriting(oldpath, newpath string, opts ...OpOpt) error {
+	return c.copymove("RenameWithoutOverwriting", MethodMove, oldpath, newpath, "infinity", false, opts...)
+}
+
+// MoveCreate is like Rename, but first creates the parent collections of newpath,
+// even if SetAutoCreateParents(false) has been used. When the parent is known to
+// be missing, this saves the failed attempt that Rename would make before
+// creating it.
+func (c *client) MoveCreate(oldpath, newpath string, opts ...OpOpt) error {
+	if err := c.mkdirParent(newpath); err != nil {
+		return err
+	}
+	return c.copymove("MoveCreate", MethodMove, oldpath, newpath, "infinity", true, opts...)
+}
+
+// CopyCreate is like Copy, but first creates the parent collections of newpath.
+// See MoveCreate.
+func (c *client) CopyCreate(oldpath, newpath string, opts ...OpOpt) error {
+	if err := c.mkdirParent(newpath); err != nil {
+		return err
+	}
+	return c.copymove("CopyCreate", MethodCopy, oldpath, newpath, "infinity", true, opts...)
+}
+
+// mkdirParent creates the parent collections of path.
+func (c *client) mkdirParent(path string) error {
+	parent := pathpkg.Dir(withLeadingSlash(path))
+	if parent == "/" {
+		return nil
+	}
+	return c.MkdirAll(parent, 0755)
 }
 
 // Copy copies a file from oldpath to newpath.
-// If newpath already exists and is not a directory, Copy overwrites it.
-func (c *client) Copy(oldpath, newpath string) error {
-	return c.copymove(MethodCopy, oldpath, newpath, true)
+// If newpath already exists, Copy overwrites it. If it is a collection that the
+// server refuses to overwrite, it is deleted and the copy is tried again.
+func (c *client) Copy(oldpath, newpath string, opts ...OpOpt) error {
+	return c.copymove("Copy", MethodCopy, oldpath, newpath, "infinity", true, opts...)
 }
 
 // CopyWithoutOverwriting copies a file from A to B
-func (c *client) CopyWithoutOverwriting(oldpath, newpath string) error {
-	return c.copymove(MethodCopy, oldpath, newpath, false)
+func (c *client) CopyWithoutOverwriting(oldpath, newpath string, opts ...OpOpt) error {
+	return c.copymove("CopyWithoutOverwriting", MethodCopy, oldpath, newpath, "infinity", false, opts...)
+}
+
+// CopyDepth copies oldpath to newpath with an explicit depth, which is either
+// "infinity" to copy a collection and all its members (as Copy does), or "0" to
+// copy only the collection itself and its properties, creating an empty collection
+// at newpath. The depth makes no difference when copying a file.
+func (c *client) CopyDepth(oldpath, newpath string, depth string, overwrite bool, opts ...OpOpt) error {
+	if depth != "0" && depth != "infinity" {
+		return newPathErrorErr("CopyDepth", oldpath, fmt.Errorf("invalid depth %q", depth))
+	}
+	return c.copymove("CopyDepth", MethodCopy, oldpath, newpath, depth, overwrite, opts...)
+}
+
+// CopyTo copies oldpath to destURL, which is an absolute http or https URL that
+// is sent verbatim as the Destination. It may be on another server, but only
+// some servers support copying between servers; others typically respond 502 Bad
+// Gateway, which gives a *StatusError.
+func (c *client) CopyTo(oldpath string, destURL string, overwrite bool) error {
+	return c.copymoveURL("CopyTo", MethodCopy, oldpath, destURL, overwrite)
+}
+
+// MoveTo moves oldpath to destURL, which is an absolute http or https URL that
+// is sent verbatim as the Destination. See CopyTo about moving between servers.
+func (c *client) MoveTo(oldpath string, destURL string, overwrite bool) error {
+	return c.copymoveURL("MoveTo", MethodMove, oldpath, destURL, overwrite)
 }
 
 // ReadFile reads the contents of a remote file.
-func (c *client) ReadFile(path string) ([]byte, error) {
+func (c *client) ReadFile(path string, opts ...OpOpt) ([]byte, error) {
 	var stream io.ReadCloser
 	var err error
 
-	if stream, err = c.ReadStream(path); err != nil {
+	if stream, err = c.ReadStream(path, opts...); err != nil {
 		return nil, err
 	}
 	defer stream.Close()
@@ -420,105 +1299,158 @@ func (c *client) ReadFile(path string) ([]byte, error) {
 }
 
 // ReadStream reads the stream for a given path. The caller must
-// close the returned io.ReadCloser.
-func (c *client) ReadStream(path string) (io.ReadCloser, error) {
-	rs, err := c.request(http.MethodGet, withLeadingSlash(path), nil, nil)
+// close the returned io.ReadCloser. As with os.Open, if there is no such file,
+// the error is a *os.PathError that satisfies os.IsNotExist.
+func (c *client) ReadStream(path string, opts ...OpOpt) (io.ReadCloser, error) {
+	rc, _, err := c.readStream(path, opts...)
+	return rc, err
+}
+
+// ReadStreamWithResponse is like ReadStream but also returns the response, so that
+// its status and headers can be inspected. The response is returned whenever the
+// server responded, even if the error is not nil; in that case, its body has already
+// been closed. Otherwise, the caller must close the returned io.ReadCloser.
+func (c *client) ReadStreamWithResponse(path string, opts ...OpOpt) (io.ReadCloser, *http.Response, error) {
+	return c.readStream(path, opts...)
+}
+
+// SuggestedFilename gets the file name that a download response suggests in its
+// Content-Disposition header, as used by some gateways instead of the URL. The
+// extended filename* parameter of RFC 5987 is preferred, so non-ASCII names are
+// decoded. Any directory part is removed. The result is empty if there is no
+// suggestion.
+func SuggestedFilename(res *http.Response) string {
+	if res == nil {
+		return ""
+	}
+
+	_, params, err := mime.ParseMediaType(res.Header.Get("Content-Disposition"))
 	if err != nil {
-		return nil, newPathErrorErr("ReadStream", path, err)
+		return ""
+	}
+
+	name := params["filename"]
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	if name == "." || name == ".." {
+		return ""
+	}
+	return name
+}
+
+// ReadStreamIfModifiedSince reads the stream for a given path, provided that it
+// has been modified since the given time. Otherwise, the server responds 304 Not
+// Modified and the error wraps ErrNotModified. The caller must close the returned
+// io.ReadCloser.
+func (c *client) ReadStreamIfModifiedSince(path string, since time.Time) (io.ReadCloser, error) {
+	rc, _, err := c.readStream(path, func(rq *http.Request) {
+		rq.Header.Set("If-Modified-Since", since.UTC().Format(http.TimeFormat))
+	})
+	return rc, err
+}
+
+// maxErrorBody limits how much of an error response body is kept in a StatusError.
+const maxErrorBody = 200
+
+func (c *client) readStream(path string, opts ...OpOpt) (io.ReadCloser, *http.Response, error) {
+	rs, err := c.request("ReadStream", http.MethodGet, withLeadingSlash(path), nil, withOpts(c.acceptGzip, opts))
+	if err != nil {
+		return nil, nil, newPathErrorErr("ReadStream", path, err)
 	}
 
 	if rs.StatusCode == http.StatusOK {
-		return rs.Body, nil
+		if err = decompress(rs); err != nil {
+			return nil, rs, newPathErrorErr("ReadStream", path, err)
+		}
+		return rs.Body, rs, nil
 	}
 
+	data, _ := ioutil.ReadAll(io.LimitReader(rs.Body, maxErrorRead))
 	rs.Body.Close()
-	return nil, newPathError("ReadStream", path, rs.StatusCode)
-}
-
-// Open opens a file for writing.
-// func (c *client) Create(path string) (File, error) {
-// 	err := c.createParentCollection(path)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	s := c.put(path, stream)
-
-// 	switch s {
-// 	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
-// 		return nil, nil
-
-// 	default:
-// 		return nil, newPathError("Create", path, s)
-// 	}
-// }
-
-// Open opens a file for reading.
-// func (c *client) Open(path string) (File, error) {
-// 	rs, err := c.req(http.MethodGet, path, nil, nil)
-// 	if err != nil {
-// 		return nil, newPathErrorErr("Open", path, err)
-// 	}
-
-// 	if rs.StatusCode == http.StatusOK {
-// 		return rs.Body, nil
-// 	}
-
-// 	rs.Body.Close()
-// 	return nil, newPathError("Open", path, rs.StatusCode)
-// }
-
-// OpenFile is the generalized open call; most users will use Open
-// or Create instead.
-// If there is an error, it will be of type *PathError.
-// func (c *client) OpenFile(path string, flag int, perm os.FileMode) (File, error) {
-// 	if flag|os.O_RDONLY == os.O_RDONLY {
-// 		return c.Open(path)
-// 	}
-// 	// if flag|os.O_RDWR == os.O_RDWR {
-// 	// 	return c.Create(path)
-// 	// }
-// 	panic(flag)
-// }
 
-// WriteFile writes data to a given path on the webdav server.
-func (c *client) WriteFile(path string, data []byte, _ os.FileMode) error {
-	s := c.put(path, bytes.NewReader(data))
-	switch s {
+	if rs.StatusCode == http.StatusNotFound {
+		// as with os.Open, so that os.IsNotExist can be used
+		return nil, rs, newPathErrorErr("ReadStream", path, os.ErrNotExist)
+	}
 
-	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
-		return nil
+	se := newPathError("ReadStream", path, rs.StatusCode).(*StatusError)
+	se.Exception, se.Message = parseSabreError(data, rs.Header.Get("Content-Type"))
+	if se.Exception == "" && se.Message == "" {
+		if len(data) > maxErrorBody {
+			data = data[:maxErrorBody]
+		}
+		se.Body = strings.TrimSpace(string(data))
+	}
+	return nil, rs, se
+}
 
-	case 409:
-		err := c.createParentCollection(path)
-		if err != nil {
+// WriteFile writes data to a given path on the webdav server.
+func (c *client) WriteFile(path string, data []byte, _ os.FileMode, opts ...OpOpt) error {
+	s, err := c.put("WriteFile", path, bytes.NewBuffer(data), opts...)
+	if s == http.StatusConflict && !c.noParents {
+		if err = c.createParentCollection(path); err != nil {
 			return err
 		}
 
-		s = c.put(path, bytes.NewReader(data))
-		if s == http.StatusOK || s == http.StatusCreated || s == http.StatusNoContent {
-			return nil
-		}
+		_, err = c.put("WriteFile", path, bytes.NewBuffer(data), opts...)
 	}
 
-	return newPathError("WriteFile", path, s)
+	return err
 }
 
 // WriteStream writes from a stream to a resource on the webdav server.
-func (c *client) WriteStream(path string, stream io.Reader, _ os.FileMode) error {
+func (c *client) WriteStream(path string, stream io.Reader, _ os.FileMode, opts ...OpOpt) error {
 
 	err := c.createParentCollection(path)
 	if err != nil {
 		return err
 	}
 
-	s := c.put(path, stream)
+	_, err = c.put("WriteStream", path, stream, opts...)
+	return err
+}
 
-	switch s {
-	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
-		return nil
+// WriteStreamSized writes size bytes from a stream to a resource on the webdav
+// server. Unlike WriteStream, the request has a Content-Length header even when the
+// stream is not an in-memory reader, avoiding chunked transfer encoding, which some
+// servers and proxies reject with 411 Length Required. The content type ct is
+// optional.
+//
+// The stream must provide exactly size bytes. If a re-send is needed after an
+// authentication challenge and the stream could not be replayed in full, the
+// request fails rather than sending a truncated body.
+func (c *client) WriteStreamSized(path string, stream io.Reader, size int64, ct string, opts ...OpOpt) error {
+	err := c.createParentCollection(path)
+	if err != nil {
+		return err
+	}
 
-	default:
-		return newPathError("WriteStream", path, s)
+	sized := func(rq *http.Request) {
+		rq.ContentLength = size
+		if size == 0 {
+			rq.Body = http.NoBody
+		}
+		if ct != "" {
+			rq.Header.Set("Content-Type", ct)
+		}
 	}
+
+	_, err = c.put("WriteStreamSized", path, stream, append([]OpOpt{sized}, opts...)...)
+	return err
+}
+
+// WriteStreamIfMatch writes from a stream to a resource on the webdav server,
+// provided its current ETag matches etag (as returned by Stat); this allows
+// optimistic concurrency control. If the resource has changed, the server
+// responds 412 Precondition Failed and the error wraps ErrPreconditionFailed.
+func (c *client) WriteStreamIfMatch(path string, stream io.Reader, perm os.FileMode, etag string) error {
+	return c.WriteStream(path, stream, perm, WithIfMatch(etag))
+}
+
+// CreateExclusive writes from a stream to a new resource on the webdav server,
+// provided it does not already exist. If it does, the server responds 412
+// Precondition Failed and the error wraps ErrPreconditionFailed.
+func (c *client) CreateExclusive(path string, stream io.Reader, perm os.FileMode) error {
+	return c.WriteStream(path, stream, perm, WithIfNoneMatch("*"))
 }
@@ -0,0 +1,1629 @@
+package gowebdav_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/rickb777/gowebdav"
+	"github.com/rickb777/gowebdav/auth"
+	"golang.org/x/net/webdav"
+)
+
+func TestNewClient_root(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(gowebdav.NewClient("example.com/dav/").Name()).To(Equal("webdav:https://example.com/dav"))
+	g.Expect(func() { gowebdav.NewClient("ftp://example.com/dav") }).To(PanicWith(ContainSubstring("gowebdav: invalid root URL")))
+	g.Expect(func() { gowebdav.NewClient("https://example.com/dav?user=a") }).To(PanicWith(ContainSubstring("query")))
+}
+
+func TestWithPrecondition(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-Schedule-Tag-Match") != `"tag1"` {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	err := client.WriteFile("cal/event.ics", []byte("x"), 0644, gowebdav.WithPrecondition("If-Schedule-Tag-Match", `"tag1"`))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	err = client.WriteFile("cal/event.ics", []byte("x"), 0644, gowebdav.WithPrecondition("If-Schedule-Tag-Match", `"tag2"`))
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(errors.Is(err, gowebdav.ErrPreconditionFailed)).To(BeTrue())
+}
+
+func TestWithIfMatch_mutations(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var conditions []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conditions = append(conditions, r.Method+" "+r.URL.Path+" "+r.Header.Get("If-Match"))
+		switch r.Method {
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+	ifMatch := gowebdav.WithIfMatch(`"e1"`)
+
+	must(t, client.Mkdir("dir", 0755, ifMatch))
+	must(t, client.Rename("a.txt", "b.txt", ifMatch))
+	must(t, client.MoveCreate("b.txt", "dir/c.txt", ifMatch))
+	must(t, client.Remove("c.txt", ifMatch))
+	must(t, client.RemoveAll("dir", ifMatch))
+
+	g.Expect(conditions).To(Equal([]string{
+		`MKCOL /dir/ "e1"`,
+		`MOVE /a.txt "e1"`,
+		`MKCOL /dir/ `,
+		`MOVE /b.txt "e1"`,
+		`DELETE /c.txt "e1"`,
+		`DELETE /dir "e1"`,
+	}))
+}
+
+func TestSetHTTP2(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var proto string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proto = r.Proto
+	}))
+	server.EnableHTTP2 = true
+	server.TLS = &tls.Config{NextProtos: []string{"h2", "http/1.1"}}
+	server.StartTLS()
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL,
+		gowebdav.SetHttpClient(server.Client()),
+		gowebdav.SetHTTP2(false),
+		gowebdav.SetMaxIdleConnsPerHost(10))
+	g.Expect(client.Ping()).NotTo(HaveOccurred())
+	g.Expect(proto).To(Equal("HTTP/1.1"))
+
+	client = gowebdav.NewClient(server.URL,
+		gowebdav.SetHttpClient(server.Client()),
+		gowebdav.SetHTTP2(true))
+	g.Expect(client.Ping()).NotTo(HaveOccurred())
+	g.Expect(proto).To(Equal("HTTP/2.0"))
+}
+
+func TestRemoveDir(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var depths []string
+	dav := &webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			depths = append(depths, r.Header.Get("Depth"))
+		}
+		dav.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+	must(t, client.Mkdir("full", 0755))
+	must(t, client.WriteFile("full/a.txt", []byte("a"), 0644))
+	must(t, client.Mkdir("empty", 0755))
+
+	err := client.RemoveDir("full", false)
+	g.Expect(errors.Is(err, gowebdav.ErrNotEmpty)).To(BeTrue())
+	g.Expect(client.Exists("full/a.txt")).To(BeTrue())
+
+	must(t, client.RemoveDir("empty", false))
+	must(t, client.RemoveDir("full", true))
+	must(t, client.RemoveDir("missing", false))
+	g.Expect(client.Exists("empty")).To(BeFalse())
+	g.Expect(client.Exists("full")).To(BeFalse())
+
+	must(t, client.Mkdir("other", 0755))
+	must(t, client.RemoveAll("other"))
+	g.Expect(depths).To(Equal([]string{"0", "infinity", ""}))
+}
+
+func TestRemoveAll_multistatus(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// the server refuses to delete the collection because a member is locked
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.Method).To(Equal(http.MethodDelete))
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8" ?>
+			<d:multistatus xmlns:d="DAV:">
+				<d:response>
+					<d:href>/a/dir/locked.txt</d:href>
+					<d:status>HTTP/1.1 423 Locked</d:status>
+					<d:responsedescription>locked by alice</d:responsedescription>
+				</d:response>
+				<d:response>
+					<d:href>/a/dir/</d:href>
+					<d:status>HTTP/1.1 424 Failed Dependency</d:status>
+				</d:response>
+				<d:response>
+					<d:href>/a/dir/other.txt</d:href>
+					<d:status>HTTP/1.1 204 No Content</d:status>
+				</d:response>
+			</d:multistatus>`)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL + "/a")
+
+	err := client.RemoveAll("dir")
+	g.Expect(err).To(HaveOccurred())
+
+	var mse *gowebdav.MultiStatusError
+	g.Expect(errors.As(err, &mse)).To(BeTrue())
+	g.Expect(mse.Path).To(Equal("/dir"))
+	g.Expect(mse.Failures).To(Equal([]gowebdav.ResourceError{
+		{Path: "/dir/locked.txt", StatusCode: http.StatusLocked, Description: "locked by alice"},
+		{Path: "/dir/", StatusCode: http.StatusFailedDependency},
+	}))
+	g.Expect(err.Error()).To(ContainSubstring("/dir/locked.txt 423 (locked by alice)"))
+}
+
+func TestRemoveAll_multistatusSuccess(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// every member was deleted
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8" ?>
+			<d:multistatus xmlns:d="DAV:">
+				<d:response>
+					<d:href>/dir/a.txt</d:href>
+					<d:href>/dir/b.txt</d:href>
+					<d:status>HTTP/1.1 204 No Content</d:status>
+				</d:response>
+				<d:response>
+					<d:href>/dir/</d:href>
+					<d:status>HTTP/1.1 200 OK</d:status>
+				</d:response>
+			</d:multistatus>`)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	g.Expect(client.RemoveAll("dir")).To(Succeed())
+	g.Expect(client.RemoveDir("dir", true)).To(Succeed())
+}
+
+func TestCopy_multistatus(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	status := "HTTP/1.1 403 Forbidden"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.Method).To(Equal("COPY"))
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="utf-8" ?>
+			<d:multistatus xmlns:d="DAV:">
+				<d:response>
+					<d:href>/a/dst/secret.txt</d:href>
+					<d:status>%s</d:status>
+				</d:response>
+				<d:response>
+					<d:href>/a/dst/other.txt</d:href>
+					<d:status>HTTP/1.1 201 Created</d:status>
+				</d:response>
+			</d:multistatus>`, status)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL + "/a")
+
+	err := client.Copy("src", "dst")
+	g.Expect(err).To(HaveOccurred())
+
+	var mse *gowebdav.MultiStatusError
+	g.Expect(errors.As(err, &mse)).To(BeTrue())
+	g.Expect(mse.Op).To(Equal("COPY"))
+	g.Expect(mse.Failures).To(Equal([]gowebdav.ResourceError{
+		{Path: "/dst/secret.txt", StatusCode: http.StatusForbidden},
+	}))
+
+	status = "HTTP/1.1 204 No Content"
+	g.Expect(client.Copy("src", "dst")).To(Succeed())
+}
+
+func TestRename_ontoCollection(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// this server refuses to overwrite a collection, contrary to RFC 4918
+	handler := &webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	}
+	refused := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "MOVE" {
+			u, _ := url.Parse(r.Header.Get("Destination"))
+			if fi, err := handler.FileSystem.Stat(r.Context(), u.Path); err == nil && fi.IsDir() {
+				refused++
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+	must(t, client.WriteFile("a.txt", []byte("hello"), 0644))
+	must(t, client.Mkdir("dir", 0755))
+	must(t, client.WriteFile("dir/b.txt", []byte("world"), 0644))
+
+	err := client.RenameWithoutOverwriting("a.txt", "dir", gowebdav.WithIfMatch("x"))
+	g.Expect(errors.Is(err, gowebdav.ErrPreconditionFailed)).To(BeTrue())
+
+	must(t, client.Rename("a.txt", "dir"))
+	g.Expect(refused).To(Equal(2))
+
+	bs, err := client.ReadFile("dir")
+	g.Expect(string(bs), err).To(Equal("hello"))
+}
+
+func TestMoveCreate(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	handler := &webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	}
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method+" "+r.URL.Path)
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL, gowebdav.SetAutoCreateParents(false))
+	must(t, client.WriteFile("a.txt", []byte("hello"), 0644))
+
+	methods = nil
+	must(t, client.MoveCreate("a.txt", "x/y/b.txt"))
+	g.Expect(methods).To(ContainElement("MKCOL /x/"))
+	g.Expect(methods[len(methods)-1]).To(Equal("MOVE /a.txt"))
+	g.Expect(methods[:len(methods)-1]).NotTo(ContainElement("MOVE /a.txt"))
+
+	methods = nil
+	must(t, client.CopyCreate("x/y/b.txt", "x/y/c.txt"))
+	g.Expect(methods[len(methods)-1]).To(Equal("COPY /x/y/b.txt"))
+	g.Expect(methods[:len(methods)-1]).NotTo(ContainElement("COPY /x/y/b.txt"))
+
+	bs, err := client.ReadFile("x/y/c.txt")
+	g.Expect(string(bs), err).To(Equal("hello"))
+	_, err = client.Stat("a.txt")
+	g.Expect(gowebdav.IsNotFound(err)).To(BeTrue())
+}
+
+func TestCopyTo_MoveTo(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var destinations []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		destinations = append(destinations, r.Method+" "+r.Header.Get("Destination")+" "+r.Header.Get("Overwrite"))
+		if strings.Contains(r.Header.Get("Destination"), "unsupported") {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	must(t, client.CopyTo("a.txt", "https://other.example.com/dav/a%20copy.txt", true))
+	must(t, client.MoveTo("b.txt", "http://other.example.com/dav/b.txt", false))
+	g.Expect(destinations).To(Equal([]string{
+		"COPY https://other.example.com/dav/a%20copy.txt T",
+		"MOVE http://other.example.com/dav/b.txt F",
+	}))
+
+	err := client.CopyTo("a.txt", "https://unsupported.example.com/a.txt", true)
+	var se *gowebdav.StatusError
+	g.Expect(errors.As(err, &se)).To(BeTrue())
+	g.Expect(se.StatusCode).To(Equal(http.StatusBadGateway))
+
+	for _, bad := range []string{"/relative/a.txt", "ftp://other.example.com/a.txt", "https:///a.txt", "%zz"} {
+		g.Expect(client.MoveTo("a.txt", bad, true)).To(MatchError(ContainSubstring("invalid destination URL")), bad)
+	}
+	g.Expect(destinations).To(HaveLen(3))
+}
+
+func TestSetAutoCreateParents(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		w.WriteHeader(http.StatusConflict)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL, gowebdav.SetAutoCreateParents(false))
+
+	attempts := map[string]error{
+		"WriteFile":   client.WriteFile("missing/a.txt", []byte("a"), 0644),
+		"WriteStream": client.WriteStream("missing/a.txt", strings.NewReader("a"), 0644),
+		"Rename":      client.Rename("a.txt", "missing/a.txt"),
+		"Copy":        client.Copy("a.txt", "missing/a.txt"),
+	}
+
+	for op, err := range attempts {
+		var se *gowebdav.StatusError
+		g.Expect(errors.As(err, &se)).To(BeTrue(), op)
+		g.Expect(se.StatusCode).To(Equal(http.StatusConflict), op)
+	}
+	g.Expect(methods).To(ConsistOf("PUT", "PUT", "MOVE", "COPY"))
+}
+
+func TestCopy_invalidMultistatus(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	body := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	g.Expect(client.Copy("src", "dst")).To(MatchError(ContainSubstring("invalid multistatus response")))
+
+	body = `<?xml version="1.0"?><d:multistatus xmlns:d="DAV:"><d:response><d:href>/dst/a.txt</d:href>`
+	g.Expect(client.Copy("src", "dst")).To(MatchError(ContainSubstring("invalid multistatus response")))
+
+	body = `<?xml version="1.0"?><d:multistatus xmlns:d="DAV:"></d:multistatus>`
+	g.Expect(client.Copy("src", "dst")).To(Succeed())
+}
+
+func TestExists(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.Method).To(Equal(http.MethodHead))
+		switch r.URL.Path {
+		case "/a.txt":
+			w.WriteHeader(http.StatusOK)
+		case "/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	g.Expect(client.Exists("a.txt")).To(BeTrue())
+	g.Expect(client.Exists("b.txt")).To(BeFalse())
+
+	_, err := client.Exists("forbidden")
+	g.Expect(gowebdav.IsForbidden(err)).To(BeTrue())
+}
+
+func TestHead(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	modified := time.Date(2021, 4, 5, 6, 7, 8, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.Method).To(Equal(http.MethodHead))
+		if r.URL.Path != "/a.txt" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Content-Length", "123")
+		w.Header().Set("ETag", `"abc"`)
+		w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	size, ct, etag, mtime, err := client.Head("a.txt")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(size).To(Equal(int64(123)))
+	g.Expect(ct).To(Equal("text/plain"))
+	g.Expect(etag).To(Equal(`"abc"`))
+	g.Expect(mtime).To(Equal(modified))
+
+	_, _, _, _, err = client.Head("b.txt")
+	g.Expect(errors.Is(err, os.ErrNotExist)).To(BeTrue())
+	g.Expect(gowebdav.IsNotFound(err)).To(BeTrue())
+}
+
+func TestReadDir_hasSize(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprint(w, `<?xml version="1.0"?>
+			<d:multistatus xmlns:d="DAV:">
+				<d:response><d:href>/dir/</d:href><d:propstat><d:prop>
+					<d:resourcetype><d:collection/></d:resourcetype>
+				</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>
+				<d:response><d:href>/dir/empty.txt</d:href><d:propstat><d:prop>
+					<d:resourcetype/><d:getcontentlength>0</d:getcontentlength>
+				</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>
+				<d:response><d:href>/dir/unknown.txt</d:href><d:propstat><d:prop>
+					<d:resourcetype/>
+				</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>
+			</d:multistatus>`)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	files, err := client.ReadDir("dir")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(files).To(HaveLen(2))
+
+	hasSize := make(map[string]bool)
+	for _, fi := range files {
+		g.Expect(fi.Size()).To(BeZero())
+		hasSize[fi.Name()] = fi.(interface{ HasSize() bool }).HasSize()
+	}
+	g.Expect(hasSize).To(Equal(map[string]bool{"empty.txt": true, "unknown.txt": false}))
+}
+
+func TestReadDir_latin1(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// the names are ISO-8859-1 encoded; the charset is given either in the
+	// Content-Type header or in the XML declaration
+	body := "<d:multistatus xmlns:d=\"DAV:\">" +
+		"<d:response><d:href>/dir/</d:href><d:propstat><d:prop>" +
+		"<d:resourcetype><d:collection/></d:resourcetype>" +
+		"</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>" +
+		"<d:response><d:href>/dir/caf\xe9.txt</d:href><d:propstat><d:prop>" +
+		"<d:resourcetype/><d:getcontentlength>1</d:getcontentlength>" +
+		"</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>" +
+		"<d:response><d:href>/dir/na\xefve-\xfcber.txt</d:href><d:propstat><d:prop>" +
+		"<d:resourcetype/><d:getcontentlength>2</d:getcontentlength>" +
+		"</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>" +
+		"</d:multistatus>"
+
+	cases := map[string]struct{ contentType, prolog string }{
+		"header": {"text/xml; charset=ISO-8859-1", `<?xml version="1.0"?>`},
+		"prolog": {"application/xml", `<?xml version="1.0" encoding="ISO-8859-1"?>`},
+		"both":   {"application/xml; charset=latin1", `<?xml version="1.0" encoding="ISO-8859-1"?>`},
+	}
+
+	for name, c := range cases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", c.contentType)
+			w.WriteHeader(http.StatusMultiStatus)
+			fmt.Fprint(w, c.prolog+body)
+		}))
+
+		client := gowebdav.NewClient(server.URL)
+
+		files, err := client.ReadDir("dir")
+		g.Expect(err).NotTo(HaveOccurred(), name)
+		g.Expect(files).To(HaveLen(2), name)
+		g.Expect(files[0].Name()).To(Equal("café.txt"), name)
+		g.Expect(files[1].Name()).To(Equal("naïve-über.txt"), name)
+
+		server.Close()
+	}
+}
+
+func TestReadDir_extraProps(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprint(w, `<?xml version="1.0"?>
+			<d:multistatus xmlns:d="DAV:" xmlns:oc="http://owncloud.org/ns" xmlns:nc="http://nextcloud.org/ns">
+				<d:response><d:href>/dir/</d:href><d:propstat><d:prop>
+					<d:resourcetype><d:collection/></d:resourcetype>
+				</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>
+				<d:response><d:href>/dir/a.txt</d:href><d:propstat><d:prop>
+					<d:resourcetype/><d:getcontentlength>1</d:getcontentlength>
+					<oc:fileid>00000123</oc:fileid>
+					<nc:has-preview>false</nc:has-preview>
+					<oc:checksums><oc:checksum>SHA1:abc</oc:checksum></oc:checksums>
+				</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>
+				<d:response><d:href>/dir/b.txt</d:href><d:propstat><d:prop>
+					<d:resourcetype/><d:getcontentlength>2</d:getcontentlength>
+				</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>
+			</d:multistatus>`)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	files, err := client.ReadDir("dir")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(files).To(HaveLen(2))
+
+	props := files[0].(interface{ Props() map[xml.Name]string }).Props()
+	g.Expect(props).To(Equal(map[xml.Name]string{
+		{Space: "http://owncloud.org/ns", Local: "fileid"}:       "00000123",
+		{Space: "http://nextcloud.org/ns", Local: "has-preview"}: "false",
+		{Space: "http://owncloud.org/ns", Local: "checksums"}:    "<oc:checksum>SHA1:abc</oc:checksum>",
+	}))
+	g.Expect(files[1].(interface{ Props() map[xml.Name]string }).Props()).To(BeEmpty())
+}
+
+func TestReadDir_serverPrefix(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// a proxy exposes the handler, which is mounted at /a/, as /files/, so the
+	// hrefs in its responses do not match the client's root
+	handler := &webdav.Handler{
+		Prefix:     "/a/",
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.URL.Path = "/a/" + strings.TrimPrefix(r.URL.Path, "/files/")
+		r.URL.RawPath = ""
+		if d := r.Header.Get("Destination"); d != "" {
+			r.Header.Set("Destination", strings.Replace(d, "/files/", "/a/", 1))
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL + "/files")
+	must(t, client.MkdirAll("dir/sub", 0755))
+	must(t, client.WriteFile("dir/x y.txt", []byte("x"), 0644))
+
+	files, err := client.ReadDir("dir")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(files).To(HaveLen(2))
+
+	var entries []string
+	for _, f := range files {
+		entries = append(entries, f.Name()+" "+f.(interface{ Path() string }).Path())
+	}
+	g.Expect(entries).To(ConsistOf("sub /dir/sub/", "x y.txt /dir/x y.txt"))
+
+	var visited []string
+	err = client.Walk("dir", func(p string, info os.FileInfo, err error) error {
+		visited = append(visited, p)
+		return err
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(visited).To(Equal([]string{"/dir", "/dir/sub", "/dir/x y.txt"}))
+}
+
+func TestReadDir_notDirectory(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+	must(t, client.WriteFile("a.txt", []byte("a"), 0644))
+
+	files, err := client.ReadDir("a.txt")
+	g.Expect(files).To(BeNil())
+	g.Expect(errors.Is(err, gowebdav.ErrNotDirectory)).To(BeTrue())
+	g.Expect(err).To(MatchError("ReadDir /a.txt/: not a directory"))
+
+	_, err = client.ReadDir("missing")
+	g.Expect(errors.Is(err, os.ErrNotExist)).To(BeTrue())
+}
+
+func TestReadDir_truncated(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprint(w, `<?xml version="1.0"?>
+			<d:multistatus xmlns:d="DAV:">
+				<d:response><d:href>/dir/</d:href><d:propstat><d:prop>
+					<d:resourcetype><d:collection/></d:resourcetype>
+				</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>
+				<d:response><d:href>/dir/a.txt</d:href><d:propstat><d:prop>
+					<d:resourcetype/><d:getcontentlen`)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	files, err := client.ReadDir("dir")
+	g.Expect(err).To(MatchError(ContainSubstring("invalid multistatus response")))
+	g.Expect(files).To(BeNil())
+
+	_, err = client.Stat("dir")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestStat_multiplePropstats(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// the 404 propstat comes first and must not be confused with the 200 one
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprint(w, `<?xml version="1.0"?>
+			<d:multistatus xmlns:d="DAV:">
+				<d:response>
+					<d:href>/a.txt</d:href>
+					<d:propstat>
+						<d:prop><d:displayname/><d:getetag/></d:prop>
+						<d:status>HTTP/1.1 404 Not Found</d:status>
+					</d:propstat>
+					<d:propstat>
+						<d:prop>
+							<d:resourcetype/>
+							<d:getcontentlength>123</d:getcontentlength>
+							<d:getcontenttype>text/plain</d:getcontenttype>
+						</d:prop>
+						<d:status>HTTP/1.1 200 OK</d:status>
+					</d:propstat>
+				</d:response>
+			</d:multistatus>`)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	fi, err := client.Stat("a.txt")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(fi.Size()).To(Equal(int64(123)))
+	g.Expect(fi.IsDir()).To(BeFalse())
+	g.Expect(fi.(interface{ ContentType() string }).ContentType()).To(Equal("text/plain"))
+}
+
+func TestStat_nameFromHref(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// this server omits the displayname
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprint(w, `<?xml version="1.0"?>
+			<d:multistatus xmlns:d="DAV:">
+				<d:response>
+					<d:href>/dir/my%20file.txt</d:href>
+					<d:propstat>
+						<d:prop>
+							<d:displayname></d:displayname>
+							<d:resourcetype/>
+						</d:prop>
+						<d:status>HTTP/1.1 200 OK</d:status>
+					</d:propstat>
+				</d:response>
+			</d:multistatus>`)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	fi, err := client.Stat("dir/my file.txt")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(fi.Name()).To(Equal("my file.txt"))
+}
+
+func TestAuthorizeError(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL,
+		gowebdav.SetAuthentication(auth.BearerFunc(func() (string, error) {
+			return "", errors.New("cannot refresh token")
+		})))
+
+	_, err := client.ReadFile("a.txt")
+	g.Expect(err).To(MatchError(ContainSubstring("cannot refresh token")))
+
+	err = client.WriteFile("a.txt", []byte("a"), 0644)
+	g.Expect(err).To(MatchError("WriteFile a.txt: cannot refresh token"))
+
+	err = client.WriteStream("dir/a.txt", strings.NewReader("a"), 0644)
+	g.Expect(err).To(MatchError("MkdirAll /dir/: cannot refresh token"))
+
+	err = client.Mkdir("dir", 0755)
+	g.Expect(err).To(MatchError("Mkdir /dir/: cannot refresh token"))
+
+	err = client.MkdirAll("dir/sub", 0755)
+	g.Expect(err).To(MatchError(ContainSubstring("cannot refresh token")))
+
+	g.Expect(requests).To(Equal(0))
+}
+
+func TestSetPreferMinimal(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	prefer := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefer[r.Method] = r.Header.Get("Prefer")
+		if r.Header.Get("Prefer") == "return=minimal" {
+			w.Header().Set("Preference-Applied", "return=minimal")
+		}
+		switch r.Method {
+		case http.MethodGet:
+			fmt.Fprint(w, "hello")
+		case "COPY", "PROPPATCH":
+			w.WriteHeader(http.StatusOK)
+		case "MKCOL", http.MethodPut:
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL, gowebdav.SetPreferMinimal())
+
+	must(t, client.Mkdir("dir", 0755))
+	must(t, client.WriteFile("a.txt", []byte("hello"), 0644))
+	must(t, client.Copy("a.txt", "b.txt"))
+	must(t, client.Rename("b.txt", "c.txt"))
+	must(t, client.Chtimes("c.txt", time.Now(), time.Now()))
+	must(t, client.Remove("c.txt"))
+	_, err := client.ReadFile("a.txt")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	for _, m := range []string{"MKCOL", "PUT", "COPY", "MOVE", "PROPPATCH", "DELETE"} {
+		g.Expect(prefer[m]).To(Equal("return=minimal"), m)
+	}
+	g.Expect(prefer["GET"]).To(BeEmpty())
+}
+
+func TestSetAcceptLanguage(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprint(w, `<?xml version="1.0"?><d:multistatus xmlns:d="DAV:"><d:response><d:href>/a.txt</d:href>`+
+			`<d:propstat><d:prop><d:displayname>a</d:displayname></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat>`+
+			`</d:response></d:multistatus>`)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL, gowebdav.SetAcceptLanguage("en-US"))
+
+	_, err := client.Stat("a.txt")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(header.Values("Accept-Language")).To(Equal([]string{"en-US"}))
+	g.Expect(header.Get("Accept")).To(Equal("application/xml,text/xml"))
+	g.Expect(header.Get("Accept-Charset")).To(Equal("utf-8"))
+}
+
+func TestSetUserAgent(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+	}))
+	defer server.Close()
+
+	must(t, gowebdav.NewClient(server.URL).Ping())
+	g.Expect(userAgent).To(HavePrefix("gowebdav"))
+	g.Expect(userAgent).NotTo(ContainSubstring("Go-http-client"))
+
+	must(t, gowebdav.NewClient(server.URL, gowebdav.AddHeader("User-Agent", "by-header/1.0")).Ping())
+	g.Expect(userAgent).To(Equal("by-header/1.0"))
+
+	must(t, gowebdav.NewClient(server.URL,
+		gowebdav.SetUserAgent("sync-tool/2.1"),
+		gowebdav.AddHeader("User-Agent", "by-header/1.0")).Ping())
+	g.Expect(userAgent).To(Equal("sync-tool/2.1"))
+}
+
+func TestWithHeaders(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var received []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Clone())
+		if r.Method == http.MethodPut {
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL,
+		gowebdav.AddHeader("X-Request-ID", "client"),
+		gowebdav.AddHeader("X-Other", "kept"))
+
+	h := http.Header{}
+	h.Set("X-Request-ID", "one-off")
+	h.Set("OC-Total-Length", "5")
+
+	must(t, client.WriteStream("a.txt", strings.NewReader("hello"), 0644, gowebdav.WithHeaders(h)))
+	rc, err := client.ReadStream("a.txt", gowebdav.WithHeaders(h))
+	g.Expect(err).NotTo(HaveOccurred())
+	rc.Close()
+	_, err = client.ReadFile("a.txt")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(received).To(HaveLen(3))
+	for _, rh := range received[:2] {
+		g.Expect(rh.Values("X-Request-ID")).To(Equal([]string{"one-off"}))
+		g.Expect(rh.Get("OC-Total-Length")).To(Equal("5"))
+		g.Expect(rh.Get("X-Other")).To(Equal("kept"))
+	}
+	g.Expect(received[2].Get("X-Request-ID")).To(Equal("client"))
+	g.Expect(received[2].Get("OC-Total-Length")).To(BeEmpty())
+}
+
+func TestWriteStreamIfMatch(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	etag := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if im := r.Header.Get("If-Match"); im != "" && im != etag {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		if r.Header.Get("If-None-Match") == "*" && etag != "" {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		etag = `"v1"`
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	must(t, client.CreateExclusive("a.txt", strings.NewReader("one"), 0644))
+
+	err := client.CreateExclusive("a.txt", strings.NewReader("two"), 0644)
+	g.Expect(errors.Is(err, gowebdav.ErrPreconditionFailed)).To(BeTrue())
+
+	must(t, client.WriteStreamIfMatch("a.txt", strings.NewReader("three"), 0644, "v1"))
+	must(t, client.WriteStreamIfMatch("a.txt", strings.NewReader("three"), 0644, `"v1"`))
+
+	err = client.WriteStreamIfMatch("a.txt", strings.NewReader("four"), 0644, `"v0"`)
+	g.Expect(errors.Is(err, gowebdav.ErrPreconditionFailed)).To(BeTrue())
+}
+
+func TestReadStreamWithResponse(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/busy":
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, "maintenance until noon "+strings.Repeat("x", 300))
+		default:
+			w.Header().Set("ETag", `"v1"`)
+			fmt.Fprint(w, "hello")
+		}
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	rc, res, err := client.ReadStreamWithResponse("/a.txt")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(res.Header.Get("ETag")).To(Equal(`"v1"`))
+	bs, _ := io.ReadAll(rc)
+	rc.Close()
+	g.Expect(string(bs)).To(Equal("hello"))
+
+	rc, res, err = client.ReadStreamWithResponse("/busy")
+	g.Expect(rc).To(BeNil())
+	g.Expect(res.StatusCode).To(Equal(http.StatusServiceUnavailable))
+	g.Expect(res.Header.Get("Retry-After")).To(Equal("120"))
+
+	var se *gowebdav.StatusError
+	g.Expect(errors.As(err, &se)).To(BeTrue())
+	g.Expect(se.Body).To(HavePrefix("maintenance until noon"))
+	g.Expect(len(se.Body)).To(Equal(200))
+	g.Expect(err.Error()).To(HavePrefix("ReadStream /busy: 503: maintenance until noon"))
+}
+
+func TestSuggestedFilename(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cases := map[string]string{
+		`attachment; filename="report.pdf"`:                                    "report.pdf",
+		`attachment; filename=plain.txt`:                                       "plain.txt",
+		`attachment; filename="fallback.txt"; filename*=UTF-8''na%C3%AFve.txt`: "naïve.txt",
+		`inline; filename*=utf-8''%E2%82%AC%20rates.csv`:                       "€ rates.csv",
+		`attachment; filename="../../etc/passwd"`:                              "passwd",
+		`attachment; filename="C:\\temp\\a.txt"`:                               "a.txt",
+		`attachment`:                                                           "",
+		`attachment; filename=".."`:                                            "",
+		``:                                                                     "",
+	}
+
+	for header, expected := range cases {
+		res := &http.Response{Header: http.Header{}}
+		if header != "" {
+			res.Header.Set("Content-Disposition", header)
+		}
+		g.Expect(gowebdav.SuggestedFilename(res)).To(Equal(expected), header)
+	}
+
+	g.Expect(gowebdav.SuggestedFilename(nil)).To(BeEmpty())
+}
+
+func TestStatusError(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		case "/full":
+			w.WriteHeader(http.StatusInsufficientStorage)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	_, err := client.ReadStream("/forbidden")
+	g.Expect(err).To(MatchError("ReadStream /forbidden: 403"))
+	g.Expect(gowebdav.IsForbidden(err)).To(BeTrue())
+	g.Expect(gowebdav.IsNotFound(err)).To(BeFalse())
+
+	var se *gowebdav.StatusError
+	g.Expect(errors.As(err, &se)).To(BeTrue())
+	g.Expect(se.StatusCode).To(Equal(http.StatusForbidden))
+	g.Expect(se.Status).To(Equal("403 Forbidden"))
+
+	err = client.WriteFile("/full", []byte("x"), 0644)
+	g.Expect(gowebdav.IsInsufficientStorage(err)).To(BeTrue())
+
+	_, err = client.ReadStream("/missing")
+	g.Expect(err).To(MatchError("ReadStream /missing: file does not exist"))
+	g.Expect(gowebdav.IsNotFound(err)).To(BeTrue())
+	g.Expect(errors.Is(err, os.ErrNotExist)).To(BeTrue())
+	g.Expect(os.IsNotExist(err)).To(BeTrue())
+
+	_, err = client.Stat("/missing")
+	g.Expect(errors.As(err, &se)).To(BeTrue())
+	g.Expect(se.StatusCode).To(Equal(http.StatusNotFound))
+	g.Expect(err).To(MatchError("PROPFIND /missing: 404"))
+	g.Expect(errors.Is(err, os.ErrNotExist)).To(BeTrue())
+
+	_, err = client.ReadDir("/missing")
+	g.Expect(errors.As(err, &se)).To(BeTrue())
+	g.Expect(se.StatusCode).To(Equal(http.StatusNotFound))
+
+	err = client.Walk("/missing", func(path string, info os.FileInfo, err error) error { return err })
+	g.Expect(errors.As(err, &se)).To(BeTrue())
+	g.Expect(se.StatusCode).To(Equal(http.StatusNotFound))
+}
+
+func TestStatusError_sabre(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/plain" {
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, "go away")
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8"?>
+			<d:error xmlns:d="DAV:" xmlns:s="http://sabredav.org/ns">
+				<s:sabredav-version>4.4.0</s:sabredav-version>
+				<s:exception>Sabre\DAV\Exception\Forbidden</s:exception>
+				<s:message>Filename contains at least one invalid character</s:message>
+			</d:error>`)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	err := client.WriteFile("a:b.txt", []byte("x"), 0644)
+	g.Expect(err).To(MatchError(`WriteFile a:b.txt: 403: Sabre\DAV\Exception\Forbidden: Filename contains at least one invalid character`))
+
+	var se *gowebdav.StatusError
+	g.Expect(errors.As(err, &se)).To(BeTrue())
+	g.Expect(se.Exception).To(Equal(`Sabre\DAV\Exception\Forbidden`))
+	g.Expect(se.Message).To(Equal("Filename contains at least one invalid character"))
+
+	_, err = client.ReadStream("a:b.txt")
+	g.Expect(err).To(MatchError(`ReadStream a:b.txt: 403: Sabre\DAV\Exception\Forbidden: Filename contains at least one invalid character`))
+
+	_, err = client.Stat("a:b.txt")
+	g.Expect(errors.As(err, &se)).To(BeTrue())
+	g.Expect(se.Exception).To(Equal(`Sabre\DAV\Exception\Forbidden`))
+
+	err = client.Rename("a:b.txt", "c.txt")
+	g.Expect(errors.As(err, &se)).To(BeTrue())
+	g.Expect(se.Message).To(Equal("Filename contains at least one invalid character"))
+
+	_, err = client.ReadStream("plain")
+	g.Expect(err).To(MatchError("ReadStream plain: 403: go away"))
+	err = client.WriteFile("plain", []byte("x"), 0644)
+	g.Expect(err).To(MatchError("WriteFile plain: 403"))
+}
+
+func TestMkdir(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	// created
+	g.Expect(client.Mkdir("dir", 0755)).To(Succeed())
+
+	// already exists
+	err := client.Mkdir("dir", 0755)
+	g.Expect(os.IsExist(err)).To(BeTrue())
+	g.Expect(errors.Is(err, os.ErrExist)).To(BeTrue())
+
+	// parent missing
+	err = client.Mkdir("missing/dir", 0755)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(os.IsExist(err)).To(BeFalse())
+	var se *gowebdav.StatusError
+	g.Expect(errors.As(err, &se)).To(BeTrue())
+	g.Expect(se.StatusCode).To(Equal(http.StatusConflict))
+
+	// MkdirAll is idempotent
+	g.Expect(client.MkdirAll("dir", 0755)).To(Succeed())
+	g.Expect(client.MkdirAll("dir/a/b", 0755)).To(Succeed())
+	g.Expect(client.MkdirAll("dir/a/b", 0755)).To(Succeed())
+}
+
+func TestCreateCollection(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	g.Expect(client.CreateCollection("dir")).To(BeTrue())
+	g.Expect(client.CreateCollection("dir")).To(BeFalse())
+
+	created, err := client.CreateCollection("missing/dir")
+	g.Expect(created).To(BeFalse())
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestMkdir_conflictWhenExists(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// some servers respond 409 rather than 405 when the collection exists
+	handler := &webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "MKCOL" {
+			if _, err := handler.FileSystem.Stat(r.Context(), r.URL.Path); err == nil {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	g.Expect(client.Mkdir("dir", 0755)).To(Succeed())
+	g.Expect(os.IsExist(client.Mkdir("dir", 0755))).To(BeTrue())
+	g.Expect(client.MkdirAll("dir", 0755)).To(Succeed())
+}
+
+func TestSpecialCharacterNames(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	names := []string{"a b.txt", "a#b.txt", "a?b.txt", "a+b.txt", "ünïcödé.txt", "a%20b.txt"}
+	must(t, client.Mkdir("dir x", 0755))
+	for _, name := range names {
+		must(t, client.WriteFile("dir x/"+name, []byte(name), 0644))
+	}
+
+	for _, name := range names {
+		data, err := client.ReadFile("dir x/" + name)
+		g.Expect(err).NotTo(HaveOccurred(), name)
+		g.Expect(string(data)).To(Equal(name))
+	}
+
+	files, err := client.ReadDir("dir x")
+	g.Expect(err).NotTo(HaveOccurred())
+	var got []string
+	for _, fi := range files {
+		got = append(got, fi.Name())
+	}
+	g.Expect(got).To(ConsistOf(names))
+
+	must(t, client.Rename("dir x/a b.txt", "dir x/c d.txt"))
+	_, err = client.Stat("dir x/c d.txt")
+	g.Expect(err).NotTo(HaveOccurred())
+}
+
+func TestReadDirFunc(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	must(t, client.Mkdir("dir", 0755))
+	for _, f := range []string{"dir/a.txt", "dir/b.txt", "dir/c.txt"} {
+		must(t, client.WriteFile(f, []byte("x"), 0644))
+	}
+
+	var names []string
+	err := client.ReadDirFunc("dir", func(fi os.FileInfo) error {
+		names = append(names, fi.Name())
+		return nil
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(names).To(ConsistOf("a.txt", "b.txt", "c.txt"))
+
+	stop := errors.New("stop")
+	count := 0
+	err = client.ReadDirFunc("dir", func(fi os.FileInfo) error {
+		count++
+		return stop
+	})
+	g.Expect(err).To(BeIdenticalTo(stop))
+	g.Expect(count).To(Equal(1))
+}
+
+func TestSetClientCertificate(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	must(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	must(t, err)
+	clientCert, err := x509.ParseCertificate(der)
+	must(t, err)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+
+	var commonName string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		commonName = r.TLS.PeerCertificates[0].Subject.CommonName
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(server.Certificate())
+
+	client := gowebdav.NewClient(server.URL, gowebdav.SetRootCAs(rootCAs))
+	g.Expect(client.Ping()).To(HaveOccurred())
+
+	client = gowebdav.NewClient(server.URL,
+		gowebdav.SetRootCAs(rootCAs),
+		gowebdav.SetClientCertificate(tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}))
+	g.Expect(client.Ping()).NotTo(HaveOccurred())
+	g.Expect(commonName).To(Equal("client"))
+}
+
+func TestPingPath(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.Method).To(Equal(http.MethodOptions))
+		switch r.URL.Path {
+		case "/gw/dav/":
+			w.WriteHeader(http.StatusNotFound)
+		case "/gw/dav/files/":
+			w.WriteHeader(http.StatusNoContent)
+		case "/gw/dav/readonly/":
+			w.Header().Set("DAV", "1, 2")
+			w.WriteHeader(http.StatusForbidden)
+		case "/gw/dav/private/":
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL + "/gw/dav")
+
+	g.Expect(client.Ping()).To(MatchError(ContainSubstring("/gw/dav/")))
+	g.Expect(client.PingPath("/files/")).To(Succeed())
+	g.Expect(client.PingPath("/readonly/")).To(Succeed())
+	g.Expect(client.PingPath("/private/")).To(HaveOccurred())
+}
+
+func TestEnableCookieJar(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	logins := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := r.Cookie("session"); err != nil {
+			logins++
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc123", Path: "/"})
+		}
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL, gowebdav.EnableCookieJar())
+	must(t, client.Ping())
+	must(t, client.Ping())
+	g.Expect(logins).To(Equal(1))
+
+	// the shared default client is not altered
+	must(t, gowebdav.NewClient(server.URL).Ping())
+	g.Expect(logins).To(Equal(2))
+}
+
+func TestTouch(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	must(t, client.Touch("a/b/marker.lock"))
+	fi, err := client.Stat("a/b/marker.lock")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(fi.IsDir()).To(BeFalse())
+	g.Expect(fi.Size()).To(BeZero())
+
+	must(t, client.WriteFile("a/data.txt", []byte("keep me"), 0644))
+	must(t, client.Touch("a/data.txt"))
+	bs, err := client.ReadFile("a/data.txt")
+	g.Expect(string(bs), err).To(Equal("keep me"))
+}
+
+func TestChtimes(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+	must(t, client.WriteFile("a.txt", []byte("x"), 0644))
+
+	mtime := time.Date(2020, 2, 29, 12, 30, 0, 0, time.UTC)
+	must(t, client.Chtimes("a.txt", mtime.Add(time.Hour), mtime))
+
+	lastModified := xml.Name{Space: "urn:schemas-microsoft-com:", Local: "Win32LastModifiedTime"}
+	props, err := client.PropFind("a.txt", 0, []xml.Name{lastModified})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(props["/a.txt"][lastModified]).To(Equal("Sat, 29 Feb 2020 12:30:00 GMT"))
+}
+
+func TestChtimes_rejected(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.Method).To(Equal("PROPPATCH"))
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprint(w, `<?xml version="1.0"?>
+			<d:multistatus xmlns:d="DAV:" xmlns:z="urn:schemas-microsoft-com:">
+				<d:response>
+					<d:href>/a.txt</d:href>
+					<d:propstat>
+						<d:prop><z:Win32LastModifiedTime/></d:prop>
+						<d:status>HTTP/1.1 403 Forbidden</d:status>
+					</d:propstat>
+					<d:propstat>
+						<d:prop><z:Win32LastAccessTime/></d:prop>
+						<d:status>HTTP/1.1 424 Failed Dependency</d:status>
+					</d:propstat>
+				</d:response>
+			</d:multistatus>`)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	err := client.Chtimes("a.txt", time.Now(), time.Now())
+	g.Expect(gowebdav.IsForbidden(err)).To(BeTrue())
+}
+
+func TestMultiStatus_allSuccess(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// each operation reports success in a 207 response, which it must not treat as failure
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMultiStatus)
+		if r.URL.Path == "/bad" {
+			fmt.Fprint(w, `<d:multistatus xmlns:d="DAV:"><d:response>`)
+			return
+		}
+		fmt.Fprint(w, `<?xml version="1.0"?>
+			<d:multistatus xmlns:d="DAV:">
+				<d:response>
+					<d:href>/a/</d:href>
+					<d:status>HTTP/1.1 201 Created</d:status>
+				</d:response>
+				<d:response>
+					<d:href>/a/b.txt</d:href>
+					<d:propstat><d:prop><d:getlastmodified/></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat>
+				</d:response>
+			</d:multistatus>`)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	g.Expect(client.Rename("a", "b")).To(Succeed())
+	g.Expect(client.Copy("a", "b")).To(Succeed())
+	g.Expect(client.RemoveAll("a")).To(Succeed())
+	g.Expect(client.Chtimes("a", time.Now(), time.Now())).To(Succeed())
+
+	g.Expect(client.Copy("bad", "b")).To(MatchError(ContainSubstring("invalid multistatus response")))
+	g.Expect(client.Chtimes("bad", time.Now(), time.Now())).To(MatchError(ContainSubstring("invalid multistatus response")))
+}
+
+func TestWriteStreamSized(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.ContentLength < 0 {
+			w.WriteHeader(http.StatusLengthRequired)
+			return
+		}
+		g.Expect(r.TransferEncoding).To(BeEmpty())
+		g.Expect(r.ContentLength).To(Equal(int64(11)))
+		g.Expect(r.Header.Get("Content-Type")).To(Equal("text/plain"))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	pr, pw := io.Pipe()
+	go func() {
+		io.WriteString(pw, "Hello World")
+		pw.Close()
+	}()
+	must(t, client.WriteStreamSized("a.txt", pr, 11, "text/plain"))
+
+	pr, pw = io.Pipe()
+	go func() {
+		io.WriteString(pw, "Hello World")
+		pw.Close()
+	}()
+	err := client.WriteStream("a.txt", pr, 0644)
+	g.Expect(err).To(MatchError("WriteStream a.txt: 411"))
+}
+
+func TestDeferredAuthentication(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var uploaded string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pw, ok := r.BasicAuth()
+		if !ok || user != "user1" || pw != "secret" {
+			w.Header().Set("Www-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		bs, _ := io.ReadAll(r.Body)
+		uploaded = string(bs)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL, gowebdav.SetAuthentication(auth.Deferred("user1", "secret")))
+
+	// not a *bytes.Buffer, so it is buffered for the re-send after the challenge
+	must(t, client.WriteStream("a.txt", strings.NewReader("Hello World"), 0644))
+	g.Expect(uploaded).To(Equal("Hello World"))
+}
+
+// seekCounter is a stream that can seek, counting how many times it does so.
+type seekCounter struct {
+	*strings.Reader
+	seeks int
+}
+
+func (s *seekCounter) Seek(offset int64, whence int) (int64, error) {
+	s.seeks++
+	return s.Reader.Seek(offset, whence)
+}
+
+func TestDeferredAuthentication_seekableStream(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var uploads []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bs, _ := io.ReadAll(r.Body)
+		if _, _, ok := r.BasicAuth(); !ok {
+			w.Header().Set("Www-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		uploads = append(uploads, string(bs))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	newClient := func() gowebdav.Client {
+		return gowebdav.NewClient(server.URL, gowebdav.SetAuthentication(auth.Deferred("user1", "secret")))
+	}
+
+	// the stream is re-read from where it was, not buffered
+	stream := &seekCounter{Reader: strings.NewReader("skip Hello World")}
+	_, _ = stream.Seek(5, io.SeekStart)
+	must(t, newClient().WriteStream("a.txt", stream, 0644))
+	g.Expect(stream.seeks).To(BeNumerically(">", 1))
+
+	// a file is not closed by the client, so it can be sent again
+	local := filepath.Join(t.TempDir(), "b.txt")
+	must(t, os.WriteFile(local, []byte("Hello File"), 0644))
+	must(t, newClient().PutFromFile("b.txt", local))
+
+	g.Expect(uploads).To(Equal([]string{"Hello World", "Hello File"}))
+}
+
+func TestDeferredAuthentication_prefersDigest(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		if authorization == "" {
+			w.Header().Add("Www-Authenticate", `Basic realm="test"`)
+			w.Header().Add("Www-Authenticate", `Digest realm="test", nonce="abc", qop="auth,auth-int"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL, gowebdav.SetAuthentication(auth.Deferred("user1", "secret")))
+
+	g.Expect(client.Exists("a.txt")).To(BeTrue())
+	g.Expect(authorization).To(HavePrefix("Digest "))
+	g.Expect(authorization).To(ContainSubstring("qop=auth"))
+}
+
+func TestDeferredAuthentication_reusesNegotiatedScheme(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	for _, scheme := range []string{"Basic", "Digest"} {
+		var attempts []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorization := r.Header.Get("Authorization")
+			attempts = append(attempts, r.Method+" "+strings.SplitN(authorization, " ", 2)[0])
+			if authorization == "" {
+				w.Header().Set("Www-Authenticate", scheme+` realm="test", nonce="abc", qop="auth"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		}))
+
+		client := gowebdav.NewClient(server.URL, gowebdav.SetAuthentication(auth.Deferred("user1", "secret")))
+
+		g.Expect(client.Exists("a.txt")).To(BeTrue(), scheme)
+		must(t, client.WriteFile("a.txt", []byte("Hello"), 0644))
+		must(t, client.Mkdir("dir", 0755))
+
+		// only the first operation is challenged
+		g.Expect(attempts).To(Equal([]string{"HEAD ", "HEAD " + scheme, "PUT " + scheme, "MKCOL " + scheme}), scheme)
+		server.Close()
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+	must(t, client.Mkdir("dir", 0755))
+
+	classes, methods, err := client.Capabilities("dir")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(classes).To(Equal([]string{"1", "2"}))
+	g.Expect(methods).To(ContainElements("OPTIONS", "PROPFIND", "LOCK"))
+}
+
+func TestReadStreamIfModifiedSince(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+	must(t, client.WriteFile("a.txt", []byte("Hello World"), 0644))
+
+	rc, err := client.ReadStreamIfModifiedSince("a.txt", time.Now().Add(-time.Hour))
+	g.Expect(err).NotTo(HaveOccurred())
+	bs, err := io.ReadAll(rc)
+	g.Expect(string(bs), err).To(Equal("Hello World"))
+	must(t, rc.Close())
+
+	rc, err = client.ReadStreamIfModifiedSince("a.txt", time.Now().Add(time.Hour))
+	g.Expect(rc).To(BeNil())
+	g.Expect(errors.Is(err, gowebdav.ErrNotModified)).To(BeTrue())
+}
@@ -187,16 +187,40 @@ func cmdStat(c d.Client, p ...string) (err error) {
 func cmdGet(c d.Client, p ...string) (err error) {
 	failIfTooManyArgs(p, 2)
 
-	bytes, err := c.ReadFile(p[0])
-	if err == nil {
-		p1 := filepath.Join(".", p[0])
-		if len(p) > 1 {
-			p1 = p[1]
-		}
-		err = writeFile(p1, bytes, 0644)
-		if err == nil {
-			fmt.Println(fmt.Sprintf("Written %d bytes to: %s", len(bytes), p1))
+	if len(p) > 1 {
+		if err = c.GetToFile(p[0], p[1], 0644); err == nil {
+			fmt.Println("Get: " + p[0] + " -> " + p[1])
 		}
+		return
+	}
+
+	// without a local path, use the name suggested by the server, if any
+	stream, res, err := c.ReadStreamWithResponse(p[0])
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	p1 := filepath.Join(".", p[0])
+	if name := d.SuggestedFilename(res); name != "" {
+		p1 = filepath.Join(filepath.Dir(p1), name)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(p1), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(p1, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(f, stream)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err == nil {
+		fmt.Println("Get: " + p[0] + " -> " + p1)
 	}
 	return
 }
@@ -254,58 +278,8 @@ func cmdPut(c d.Client, p ...string) (err error) {
 		p1 = p[1]
 	}
 
-	stream, err := getStream(p1)
-	if err != nil {
-		return
-	}
-	defer stream.Close()
-
-	if err = c.WriteStream(p[0], stream, 0644); err == nil {
+	if err = c.PutFromFile(p[0], p1); err == nil {
 		fmt.Println("Put: " + p1 + " -> " + p[0])
 	}
 	return
 }
-
-func writeFile(path string, bytes []byte, mode os.FileMode) error {
-	parent := filepath.Dir(path)
-	if _, e := os.Stat(parent); os.IsNotExist(e) {
-		if e := os.MkdirAll(parent, os.ModePerm); e != nil {
-			return e
-		}
-	}
-
-	f, err := os.Create(path)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	_, err = f.Write(bytes)
-	return err
-}
-
-func getStream(pathOrString string) (io.ReadCloser, error) {
-	fi, err := os.Stat(pathOrString)
-	if err != nil {
-		return nil, err
-	}
-
-	if fi.IsDir() {
-		return nil, &os.PathError{
-			Op:   "Open",
-			Path: pathOrString,
-			Err:  errors.New("Path: '" + pathOrString + "' is a directory"),
-		}
-	}
-
-	f, err := os.Open(pathOrString)
-	if err == nil {
-		return f, nil
-	}
-
-	return nil, &os.PathError{
-		Op:   "Open",
-		Path: pathOrString,
-		Err:  err,
-	}
-}
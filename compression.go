@@ -0,0 +1,60 @@
+package gowebdav
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// EnableCompression requests gzip-compressed responses for PROPFIND and GET, and
+// decompresses them transparently. Large directory listings compress well.
+//
+// This is only needed when the HttpClient does not already do this itself; the
+// default http.Transport does so, unless its DisableCompression is set.
+func EnableCompression() ClientOpt {
+	return func(c Client) {
+		c.(*client).compression = true
+	}
+}
+
+// acceptGzip sets the Accept-Encoding header if compression is enabled.
+func (c *client) acceptGzip(rq *http.Request) {
+	if c.compression {
+		rq.Header.Set("Accept-Encoding", "gzip")
+	}
+}
+
+// decompress replaces the body of a gzip-encoded response with its decompressed
+// content. Closing the new body closes the original one too.
+func decompress(res *http.Response) error {
+	if !strings.EqualFold(res.Header.Get("Content-Encoding"), "gzip") {
+		return nil
+	}
+
+	zr, err := gzip.NewReader(res.Body)
+	if err != nil {
+		res.Body.Close()
+		return err
+	}
+
+	res.Body = &gzipReadCloser{Reader: zr, body: res.Body}
+	res.Header.Del("Content-Encoding")
+	res.Header.Del("Content-Length")
+	res.ContentLength = -1
+	res.Uncompressed = true
+	return nil
+}
+
+type gzipReadCloser struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (g *gzipReadCloser) Close() error {
+	err := g.Reader.Close()
+	if e := g.body.Close(); err == nil {
+		err = e
+	}
+	return err
+}
@@ -0,0 +1,88 @@
+package gowebdav_test
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/rickb777/gowebdav"
+)
+
+type closeRecorder struct {
+	io.ReadCloser
+	closed *int
+}
+
+func (c closeRecorder) Close() error {
+	*c.closed++
+	return c.ReadCloser.Close()
+}
+
+type recordingTransport struct {
+	closed int
+}
+
+func (t *recordingTransport) RoundTrip(rq *http.Request) (*http.Response, error) {
+	res, err := http.DefaultTransport.RoundTrip(rq)
+	if err == nil {
+		res.Body = closeRecorder{ReadCloser: res.Body, closed: &t.closed}
+	}
+	return res, err
+}
+
+func TestEnableCompression(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.Header.Get("Accept-Encoding")).To(Equal("gzip"))
+
+		w.Header().Set("Content-Encoding", "gzip")
+		if r.Method == "PROPFIND" {
+			w.WriteHeader(http.StatusMultiStatus)
+		}
+
+		zw := gzip.NewWriter(w)
+		defer zw.Close()
+
+		if r.Method == "PROPFIND" {
+			io.WriteString(zw, `<?xml version="1.0"?>
+				<d:multistatus xmlns:d="DAV:">
+					<d:response>
+						<d:href>/a.txt</d:href>
+						<d:propstat>
+							<d:prop>
+								<d:displayname>a.txt</d:displayname>
+								<d:getcontentlength>11</d:getcontentlength>
+							</d:prop>
+							<d:status>HTTP/1.1 200 OK</d:status>
+						</d:propstat>
+					</d:response>
+				</d:multistatus>`)
+		} else {
+			io.WriteString(zw, "Hello World")
+		}
+	}))
+	defer server.Close()
+
+	rt := &recordingTransport{}
+	client := gowebdav.NewClient(server.URL,
+		gowebdav.SetHttpClient(&http.Client{Transport: rt}),
+		gowebdav.EnableCompression())
+
+	fi, err := client.Stat("/a.txt")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(fi.Size()).To(Equal(int64(11)))
+
+	rc, err := client.ReadStream("/a.txt")
+	g.Expect(err).NotTo(HaveOccurred())
+	bs, err := ioutil.ReadAll(rc)
+	g.Expect(string(bs), err).To(Equal("Hello World"))
+
+	closed := rt.closed
+	must(t, rc.Close())
+	g.Expect(rt.closed).To(Equal(closed + 1))
+}
@@ -0,0 +1,247 @@
+package gowebdav
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrPreconditionFailed is wrapped by the error returned when the server rejects a
+// conditional request with 412 Precondition Failed. Use errors.Is to test for it.
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+// ErrChecksumMismatch is wrapped by the error returned when a downloaded file does
+// not have the expected checksum.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ErrPartialUpdateNotSupported is wrapped by the error returned by WriteStreamAt
+// when the server does not support partial updates.
+var ErrPartialUpdateNotSupported = errors.New("partial update not supported")
+
+// ErrDepthInfinityNotSupported is wrapped by the error returned by ReadDirInfinite
+// when the server refuses a Depth: infinity PROPFIND. Walk can be used instead.
+var ErrDepthInfinityNotSupported = errors.New("depth infinity not supported; use Walk instead")
+
+// ErrNotEmpty is wrapped by the error returned by RemoveDir when a collection that
+// is to be removed without its members is not empty.
+var ErrNotEmpty = errors.New("collection not empty")
+
+// ErrNotDirectory is wrapped by the error returned when a directory operation such
+// as ReadDir is applied to a resource that is not a collection, typically a file.
+var ErrNotDirectory = errors.New("not a directory")
+
+// ErrTooDeep is wrapped by the error passed to the Walk function for a collection
+// that is nested too deeply to be listed, which usually means there is a loop.
+var ErrTooDeep = errors.New("too deeply nested; there may be a loop")
+
+// ErrWalkLoop is wrapped by the error passed to the Walk function for a collection
+// that has already been visited under another path, because of a loop on the server.
+var ErrWalkLoop = errors.New("collection already visited; there is a loop")
+
+// ErrNotModified is wrapped by the error returned when a conditional download is
+// not needed because the server responded 304 Not Modified.
+var ErrNotModified = errors.New("not modified")
+
+// StatusError is returned when the server responds to an operation with an
+// unexpected HTTP status. A 404 response unwraps to os.ErrNotExist, a 412 response
+// unwraps to ErrPreconditionFailed and a 304 response unwraps to ErrNotModified,
+// so errors.Is can be used for these. Note that os.IsNotExist does not look inside
+// a StatusError, so use errors.Is or IsNotFound instead. Open, OpenFile, ReadFile
+// and ReadStream, like their os counterparts, give a *os.PathError that
+// os.IsNotExist recognises.
+type StatusError struct {
+	Op         string
+	Path       string
+	StatusCode int
+	Status     string // e.g. "404 Not Found"
+	Body       string // the start of the response body, if it was read
+
+	// Exception and Message are from the error body sent by servers based on
+	// sabre/dav, such as Nextcloud and ownCloud, e.g. "Sabre\DAV\Exception\Forbidden".
+	// They are empty if there was no such body.
+	Exception string
+	Message   string
+}
+
+func (e *StatusError) Error() string {
+	msg := e.Op + " " + e.Path + ": " + strconv.Itoa(e.StatusCode)
+	if e.Exception != "" {
+		msg += ": " + e.Exception
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.Body != "" {
+		msg += ": " + e.Body
+	}
+	return msg
+}
+
+// maxErrorRead limits how much of an error response body is read to look for
+// details of the error.
+const maxErrorRead = 4096
+
+// responseError gets the *StatusError for a response with an unexpected status.
+// If the response has a sabre/dav error body, the exception and message are
+// included. The body is read, but not closed.
+func responseError(op, path string, res *http.Response) error {
+	se := newPathError(op, path, res.StatusCode).(*StatusError)
+	if res.StatusCode >= 400 {
+		data, _ := ioutil.ReadAll(io.LimitReader(res.Body, maxErrorRead))
+		se.Exception, se.Message = parseSabreError(data, res.Header.Get("Content-Type"))
+	}
+	return se
+}
+
+// sabreError is the error body of sabre/dav, e.g.
+//
+//	<d:error xmlns:d="DAV:" xmlns:s="http://sabredav.org/ns">
+//	  <s:exception>Sabre\DAV\Exception\NotFound</s:exception>
+//	  <s:message>File with name a.txt could not be located</s:message>
+//	</d:error>
+type sabreError struct {
+	XMLName   xml.Name `xml:"DAV: error"`
+	Exception string   `xml:"http://sabredav.org/ns exception"`
+	Message   string   `xml:"http://sabredav.org/ns message"`
+}
+
+// parseSabreError gets the exception and message from a sabre/dav error body.
+// They are empty if the body is not XML or not of that form.
+func parseSabreError(data []byte, contentType string) (exception, message string) {
+	mt, _, _ := mime.ParseMediaType(contentType)
+	if mt != "application/xml" && mt != "text/xml" && !strings.HasSuffix(mt, "+xml") {
+		return "", ""
+	}
+
+	var e sabreError
+	decoder, err := xmlDecoder(bytes.NewReader(data), contentType)
+	if err == nil {
+		err = decoder.Decode(&e)
+	}
+	if err != nil {
+		return "", ""
+	}
+	return strings.TrimSpace(e.Exception), strings.TrimSpace(e.Message)
+}
+
+// Unwrap returns the sentinel error corresponding to the status code, if any.
+func (e *StatusError) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusNotFound:
+		return os.ErrNotExist
+	case http.StatusPreconditionFailed:
+		return ErrPreconditionFailed
+	case http.StatusNotModified:
+		return ErrNotModified
+	}
+	return nil
+}
+
+// IsNotFound reports whether err indicates that the resource does not exist.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound) || errors.Is(err, os.ErrNotExist)
+}
+
+// IsForbidden reports whether err is a 403 Forbidden response.
+func IsForbidden(err error) bool {
+	return hasStatus(err, http.StatusForbidden)
+}
+
+// IsInsufficientStorage reports whether err is a 507 Insufficient Storage response.
+func IsInsufficientStorage(err error) bool {
+	return hasStatus(err, http.StatusInsufficientStorage)
+}
+
+func hasStatus(err error, code int) bool {
+	var se *StatusError
+	return errors.As(err, &se) && se.StatusCode == code
+}
+
+// ResourceError records the status of one resource that failed within a
+// multistatus (207) response.
+type ResourceError struct {
+	Path        string
+	StatusCode  int
+	Description string // the server's responsedescription, if any
+}
+
+// MultiStatusError is returned when the server responds with 207 Multi-Status
+// and one or more of the resources involved could not be processed, for example
+// because a locked member prevented a collection being deleted.
+type MultiStatusError struct {
+	Op       string
+	Path     string
+	Failures []ResourceError
+}
+
+func (e *MultiStatusError) Error() string {
+	parts := make([]string, len(e.Failures))
+	for i, f := range e.Failures {
+		parts[i] = fmt.Sprintf("%s %d", f.Path, f.StatusCode)
+		if f.Description != "" {
+			parts[i] += " (" + f.Description + ")"
+		}
+	}
+	return fmt.Sprintf("%s %s: %d failed: %s", e.Op, e.Path, len(e.Failures), strings.Join(parts, ", "))
+}
+
+// PathFailure records why an operation on one path within a batch failed.
+type PathFailure struct {
+	Path string
+	Err  error
+}
+
+// BatchError is returned when one or more of the operations in a batch failed.
+// The other operations will have succeeded.
+type BatchError struct {
+	Op       string
+	Failures []PathFailure
+}
+
+func (e *BatchError) Error() string {
+	parts := make([]string, len(e.Failures))
+	for i, f := range e.Failures {
+		parts[i] = f.Err.Error()
+	}
+	return fmt.Sprintf("%s: %d failed: %s", e.Op, len(e.Failures), strings.Join(parts, "; "))
+}
+
+// Unwrap returns the individual errors. From Go 1.20, errors.Is and errors.As use
+// this; Is and As do the same for earlier versions.
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, f := range e.Failures {
+		errs[i] = f.Err
+	}
+	return errs
+}
+
+// Is reports whether any of the individual errors matches target, so that
+// errors.Is can be used with a BatchError.
+func (e *BatchError) Is(target error) bool {
+	for _, f := range e.Failures {
+		if errors.Is(f.Err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the individual errors that matches target, so that
+// errors.As can be used with a BatchError.
+func (e *BatchError) As(target interface{}) bool {
+	for _, f := range e.Failures {
+		if errors.As(f.Err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package gowebdav
+
+import (
+	"net/http"
+	"time"
+)
+
+// defaultExpectContinueTimeout is how long to wait for the server to accept or
+// reject a request body, if the transport does not already have a limit.
+const defaultExpectContinueTimeout = time.Second
+
+// EnableExpectContinue sends "Expect: 100-continue" with uploads, so that the
+// server can reject a request, for example because the user is over quota or the
+// path is wrong, before the body is transmitted. This saves bandwidth for large
+// files.
+//
+// The transport waits for the server's interim response for up to its
+// ExpectContinueTimeout, which is set to one second if it has none. This needs the
+// default transport or a compatible *http.Transport; see SetClientCertificate. It
+// does not help if the server ignores the Expect header, in which case the body is
+// sent after the timeout.
+func EnableExpectContinue() ClientOpt {
+	return func(c Client) {
+		cl := c.(*client)
+		cl.expectContinue = true
+		cl.transportOpts = append(cl.transportOpts, func(t *http.Transport) {
+			if t.ExpectContinueTimeout <= 0 {
+				t.ExpectContinueTimeout = defaultExpectContinueTimeout
+			}
+		})
+	}
+}
+
+// expect adds the Expect header to an upload if EnableExpectContinue is in use.
+func (c *client) expect(rq *http.Request) {
+	if c.expectContinue {
+		rq.Header.Set("Expect", "100-continue")
+	}
+}
@@ -0,0 +1,84 @@
+package gowebdav_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/rickb777/gowebdav"
+	"github.com/rickb777/gowebdav/auth"
+)
+
+// countingReader records how many bytes have been read from it.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func TestEnableExpectContinue(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var expect, uploaded string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expect = r.Header.Get("Expect")
+		if r.URL.Path == "/full.txt" {
+			// rejected without reading the body
+			w.WriteHeader(http.StatusInsufficientStorage)
+			return
+		}
+		bs, _ := io.ReadAll(r.Body)
+		uploaded = string(bs)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL, gowebdav.EnableExpectContinue())
+
+	body := &countingReader{r: strings.NewReader(strings.Repeat("x", 100000))}
+	err := client.WriteStream("full.txt", body, 0644)
+	var se *gowebdav.StatusError
+	g.Expect(errors.As(err, &se)).To(BeTrue())
+	g.Expect(se.StatusCode).To(Equal(http.StatusInsufficientStorage))
+	g.Expect(expect).To(Equal("100-continue"))
+	g.Expect(body.n).To(BeZero())
+
+	must(t, client.WriteStream("a.txt", strings.NewReader("Hello World"), 0644))
+	g.Expect(expect).To(Equal("100-continue"))
+	g.Expect(uploaded).To(Equal("Hello World"))
+}
+
+func TestEnableExpectContinue_deferredAuthentication(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var uploaded string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, _, ok := r.BasicAuth(); !ok {
+			// challenged without reading the body
+			w.Header().Set("Www-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		bs, _ := io.ReadAll(r.Body)
+		uploaded = string(bs)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL,
+		gowebdav.EnableExpectContinue(),
+		gowebdav.SetAuthentication(auth.Deferred("user1", "secret")))
+
+	// the body that was held back is sent in full after the challenge
+	must(t, client.WriteStream("a.txt", strings.NewReader("Hello World"), 0644))
+	g.Expect(uploaded).To(Equal("Hello World"))
+}
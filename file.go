@@ -0,0 +1,501 @@
+package gowebdav
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	pathpkg "path"
+
+	"github.com/spf13/afero"
+)
+
+var _ afero.File = &webdavFile{}
+
+// webdavFile implements afero.File for a remote resource.
+//
+// Files opened read-only are streamed using GET; seeking is translated into Range
+// requests. Files opened for writing are held in a local buffer, which is loaded
+// from the server when needed and written back using PUT on Sync or Close.
+type webdavFile struct {
+	c     *client
+	name  string
+	flag  int
+	perm  os.FileMode
+	info  os.FileInfo
+	isdir bool
+
+	// read-only streaming
+	rc     io.ReadCloser
+	offset int64
+
+	// buffered writing
+	data   []byte
+	loaded bool
+	dirty  bool
+
+	// directory listing
+	entries []os.FileInfo
+	listed  bool
+
+	closed bool
+}
+
+// Create creates a file, truncating it if it already exists. The file
+// is opened for reading and writing.
+func (c *client) Create(name string) (afero.File, error) {
+	return c.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+// Open opens a file or collection for reading.
+func (c *client) Open(name string) (afero.File, error) {
+	return c.OpenFile(name, os.O_RDONLY, 0)
+}
+
+// OpenFile is the generalized open call; most users will use Open
+// or Create instead. It honours O_CREATE, O_EXCL, O_APPEND and O_TRUNC,
+// checking whether the file exists using Stat.
+// If there is an error, it will be of type *PathError. As with os.OpenFile, if
+// the file does not exist, it wraps os.ErrNotExist, so os.IsNotExist can be used.
+func (c *client) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	path := withLeadingSlash(name)
+	f := &webdavFile{c: c, name: name, flag: flag, perm: perm}
+
+	info, err := c.Stat(path)
+	switch {
+	case err == nil:
+		if flag&(os.O_CREATE|os.O_EXCL) == os.O_CREATE|os.O_EXCL {
+			return nil, newPathErrorErr("OpenFile", name, os.ErrExist)
+		}
+		f.info = info
+		f.isdir = info.IsDir()
+
+	case errors.Is(err, os.ErrNotExist) && flag&os.O_CREATE != 0:
+		// the file will be created below
+
+	case errors.Is(err, os.ErrNotExist):
+		return nil, newPathErrorErr("OpenFile", name, os.ErrNotExist)
+
+	default:
+		return nil, withPathError("OpenFile", name, err)
+	}
+
+	if f.writable() {
+		if f.isdir {
+			return nil, newPathErrorErr("OpenFile", name, errors.New("is a directory"))
+		}
+
+		if f.info == nil || flag&os.O_TRUNC != 0 {
+			// create or truncate the remote file now, as the os package would
+			if err = c.WriteFile(path, nil, perm); err != nil {
+				return nil, err
+			}
+			f.loaded = true
+		}
+	}
+
+	return f, nil
+}
+
+func (f *webdavFile) path() string {
+	return withLeadingSlash(f.name)
+}
+
+func (f *webdavFile) writable() bool {
+	return f.flag&(os.O_WRONLY|os.O_RDWR) != 0
+}
+
+func (f *webdavFile) readable() bool {
+	return f.flag&os.O_WRONLY == 0
+}
+
+// load fetches the current remote content into the local buffer, once only.
+func (f *webdavFile) load() error {
+	if f.loaded {
+		return nil
+	}
+
+	data, err := f.c.ReadFile(f.path())
+	if err != nil {
+		return err
+	}
+
+	f.data = data
+	f.loaded = true
+	return nil
+}
+
+func (f *webdavFile) check(op string) error {
+	if f.closed {
+		return newPathErrorErr(op, f.name, os.ErrClosed)
+	}
+	if f.isdir {
+		return newPathErrorErr(op, f.name, errors.New("is a directory"))
+	}
+	return nil
+}
+
+// Name returns the name of the file as given to Open.
+func (f *webdavFile) Name() string {
+	return f.name
+}
+
+// Stat returns the FileInfo for the file. For a file open for writing,
+// the size reflects any unsaved writes.
+func (f *webdavFile) Stat() (os.FileInfo, error) {
+	if f.closed {
+		return nil, newPathErrorErr("Stat", f.name, os.ErrClosed)
+	}
+
+	if f.info == nil {
+		info, err := f.c.Stat(f.path())
+		if err != nil {
+			return nil, err
+		}
+		f.info = info
+	}
+
+	if f.loaded {
+		if fi, ok := f.info.(*fileinfo); ok {
+			cp := *fi
+			cp.size = int64(len(f.data))
+			return &cp, nil
+		}
+	}
+
+	return f.info, nil
+}
+
+// Read reads up to len(p) bytes from the current offset.
+func (f *webdavFile) Read(p []byte) (int, error) {
+	if err := f.check("Read"); err != nil {
+		return 0, err
+	}
+	if !f.readable() {
+		return 0, newPathErrorErr("Read", f.name, os.ErrPermission)
+	}
+
+	if f.writable() {
+		if err := f.load(); err != nil {
+			return 0, err
+		}
+		if f.offset >= int64(len(f.data)) {
+			return 0, io.EOF
+		}
+		n := copy(p, f.data[f.offset:])
+		f.offset += int64(n)
+		return n, nil
+	}
+
+	if f.rc == nil {
+		rc, err := f.c.readRange("Read", f.path(), f.offset, -1)
+		if err != nil {
+			return 0, err
+		}
+		f.rc = rc
+	}
+
+	n, err := f.rc.Read(p)
+	f.offset += int64(n)
+	return n, err
+}
+
+// ReadAt reads len(p) bytes starting at byte offset off, using a Range
+// request unless the file is open for writing.
+func (f *webdavFile) ReadAt(p []byte, off int64) (int, error) {
+	if err := f.check("ReadAt"); err != nil {
+		return 0, err
+	}
+	if !f.readable() {
+		return 0, newPathErrorErr("ReadAt", f.name, os.ErrPermission)
+	}
+	if off < 0 {
+		return 0, newPathErrorErr("ReadAt", f.name, errors.New("negative offset"))
+	}
+
+	if f.writable() {
+		if err := f.load(); err != nil {
+			return 0, err
+		}
+		if off >= int64(len(f.data)) {
+			return 0, io.EOF
+		}
+		n := copy(p, f.data[off:])
+		if n < len(p) {
+			return n, io.EOF
+		}
+		return n, nil
+	}
+
+	rc, err := f.c.readRange("ReadAt", f.path(), off, int64(len(p)))
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+
+	n, err := io.ReadFull(rc, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Seek sets the offset for the next Read or Write. When streaming, a
+// subsequent Read starts a new Range request at the new offset.
+func (f *webdavFile) Seek(offset int64, whence int) (int64, error) {
+	if err := f.check("Seek"); err != nil {
+		return 0, err
+	}
+
+	var base int64
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		base = f.offset
+	case io.SeekEnd:
+		if f.writable() {
+			if err := f.load(); err != nil {
+				return 0, err
+			}
+			base = int64(len(f.data))
+		} else {
+			info, err := f.Stat()
+			if err != nil {
+				return 0, err
+			}
+			base = info.Size()
+		}
+	default:
+		return 0, newPathErrorErr("Seek", f.name, fmt.Errorf("invalid whence %d", whence))
+	}
+
+	if base+offset < 0 {
+		return 0, newPathErrorErr("Seek", f.name, errors.New("negative offset"))
+	}
+
+	if f.rc != nil && base+offset != f.offset {
+		_ = f.rc.Close()
+		f.rc = nil
+	}
+
+	f.offset = base + offset
+	return f.offset, nil
+}
+
+// Write writes to the local buffer at the current offset, or at the end
+// if the file was opened with O_APPEND.
+func (f *webdavFile) Write(p []byte) (int, error) {
+	if err := f.check("Write"); err != nil {
+		return 0, err
+	}
+	if !f.writable() {
+		return 0, newPathErrorErr("Write", f.name, os.ErrPermission)
+	}
+	if err := f.load(); err != nil {
+		return 0, err
+	}
+
+	if f.flag&os.O_APPEND != 0 {
+		f.offset = int64(len(f.data))
+	}
+
+	n := f.writeAt(p, f.offset)
+	f.offset += int64(n)
+	return n, nil
+}
+
+// WriteAt writes to the local buffer at byte offset off.
+func (f *webdavFile) WriteAt(p []byte, off int64) (int, error) {
+	if err := f.check("WriteAt"); err != nil {
+		return 0, err
+	}
+	if !f.writable() {
+		return 0, newPathErrorErr("WriteAt", f.name, os.ErrPermission)
+	}
+	if f.flag&os.O_APPEND != 0 {
+		return 0, newPathErrorErr("WriteAt", f.name, errors.New("invalid use of WriteAt on file opened with O_APPEND"))
+	}
+	if off < 0 {
+		return 0, newPathErrorErr("WriteAt", f.name, errors.New("negative offset"))
+	}
+	if err := f.load(); err != nil {
+		return 0, err
+	}
+
+	return f.writeAt(p, off), nil
+}
+
+func (f *webdavFile) writeAt(p []byte, off int64) int {
+	end := off + int64(len(p))
+	if end > int64(len(f.data)) {
+		grown := make([]byte, end)
+		copy(grown, f.data)
+		f.data = grown
+	}
+	f.dirty = true
+	return copy(f.data[off:], p)
+}
+
+// WriteString is like Write, but writes the contents of string s.
+func (f *webdavFile) WriteString(s string) (int, error) {
+	return f.Write([]byte(s))
+}
+
+// Truncate changes the size of the local buffer.
+func (f *webdavFile) Truncate(size int64) error {
+	if err := f.check("Truncate"); err != nil {
+		return err
+	}
+	if !f.writable() {
+		return newPathErrorErr("Truncate", f.name, os.ErrPermission)
+	}
+	if size < 0 {
+		return newPathErrorErr("Truncate", f.name, errors.New("negative size"))
+	}
+	if err := f.load(); err != nil {
+		return err
+	}
+
+	if size <= int64(len(f.data)) {
+		f.data = f.data[:size]
+	} else {
+		f.data = append(f.data, make([]byte, size-int64(len(f.data)))...)
+	}
+	f.dirty = true
+	return nil
+}
+
+// Sync writes any buffered changes to the server using PUT.
+func (f *webdavFile) Sync() error {
+	if f.closed {
+		return newPathErrorErr("Sync", f.name, os.ErrClosed)
+	}
+	if !f.dirty {
+		return nil
+	}
+
+	if err := f.c.WriteFile(f.path(), f.data, f.perm); err != nil {
+		return err
+	}
+	f.dirty = false
+	return nil
+}
+
+// Close writes any buffered changes to the server and releases the file.
+func (f *webdavFile) Close() error {
+	if f.closed {
+		return newPathErrorErr("Close", f.name, os.ErrClosed)
+	}
+
+	err := f.Sync()
+
+	if f.rc != nil {
+		if e := f.rc.Close(); err == nil {
+			err = e
+		}
+		f.rc = nil
+	}
+
+	f.closed = true
+	f.data = nil
+	return err
+}
+
+// Readdir reads the contents of the collection and returns a slice of up to
+// count FileInfo values, as would be returned by ReadDir. If count <= 0,
+// all remaining entries are returned.
+func (f *webdavFile) Readdir(count int) ([]os.FileInfo, error) {
+	if f.closed {
+		return nil, newPathErrorErr("Readdir", f.name, os.ErrClosed)
+	}
+	if !f.isdir {
+		return nil, newPathErrorErr("Readdir", f.name, errors.New("not a directory"))
+	}
+
+	if !f.listed {
+		files, err := f.c.ReadDir(f.path())
+		if err != nil {
+			return nil, err
+		}
+		f.entries = files
+		f.listed = true
+	}
+
+	if count <= 0 {
+		entries := f.entries
+		f.entries = nil
+		return entries, nil
+	}
+
+	if len(f.entries) == 0 {
+		return nil, io.EOF
+	}
+
+	if count > len(f.entries) {
+		count = len(f.entries)
+	}
+	entries := f.entries[:count]
+	f.entries = f.entries[count:]
+	return entries, nil
+}
+
+// Readdirnames is like Readdir but returns only the names.
+func (f *webdavFile) Readdirnames(n int) ([]string, error) {
+	files, err := f.Readdir(n)
+	names := make([]string, len(files))
+	for i, fi := range files {
+		names[i] = pathpkg.Base(fi.Name())
+	}
+	return names, err
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// readRange gets part of a resource for op, starting at offset. If length is
+// negative, the rest of the resource is read; if it is zero, nothing is
+// requested. Servers that ignore the Range header are handled by discarding
+// the leading bytes.
+func (c *client) readRange(op, path string, offset, length int64) (io.ReadCloser, error) {
+	if length == 0 {
+		return ioutil.NopCloser(bytes.NewReader(nil)), nil
+	}
+
+	rs, err := c.request(op, http.MethodGet, withLeadingSlash(path), nil, func(rq *http.Request) {
+		if offset > 0 || length >= 0 {
+			if length < 0 {
+				rq.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+			} else {
+				rq.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+			}
+		}
+	})
+	if err != nil {
+		return nil, newPathErrorErr(op, path, err)
+	}
+
+	switch rs.StatusCode {
+	case http.StatusPartialContent:
+		return rs.Body, nil
+
+	case http.StatusOK:
+		if _, err = io.CopyN(ioutil.Discard, rs.Body, offset); err != nil {
+			rs.Body.Close()
+			if err == io.EOF {
+				return ioutil.NopCloser(bytes.NewReader(nil)), nil
+			}
+			return nil, newPathErrorErr(op, path, err)
+		}
+		return rs.Body, nil
+
+	case http.StatusRequestedRangeNotSatisfiable:
+		rs.Body.Close()
+		return ioutil.NopCloser(bytes.NewReader(nil)), nil
+	}
+
+	err = responseError(op, path, rs)
+	rs.Body.Close()
+	return nil, err
+}
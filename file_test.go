@@ -0,0 +1,155 @@
+package gowebdav_test
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/rickb777/gowebdav"
+	"golang.org/x/net/webdav"
+)
+
+func TestFile(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	t.Logf("Create\n")
+	f, err := client.Create("hello.txt")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(f.WriteString("Hello world!")).To(Equal(12))
+	g.Expect(f.WriteAt([]byte("W"), 6)).To(Equal(1))
+	must(t, f.Close())
+
+	bs, err := client.ReadFile("hello.txt")
+	g.Expect(string(bs), err).To(Equal("Hello World!"))
+
+	t.Logf("OpenFile O_EXCL\n")
+	_, err = client.OpenFile("hello.txt", os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	g.Expect(os.IsExist(err)).To(BeTrue())
+
+	t.Logf("OpenFile missing\n")
+	_, err = client.OpenFile("missing.txt", os.O_RDONLY, 0)
+	g.Expect(os.IsNotExist(err)).To(BeTrue())
+	var pe *os.PathError
+	g.Expect(errors.As(err, &pe)).To(BeTrue())
+	g.Expect(pe.Op).To(Equal("OpenFile"))
+
+	t.Logf("OpenFile O_APPEND\n")
+	f, err = client.OpenFile("hello.txt", os.O_WRONLY|os.O_APPEND, 0644)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(f.WriteString(" Bye.")).To(Equal(5))
+	fi, err := f.Stat()
+	g.Expect(fi.Size(), err).To(Equal(int64(17)))
+	must(t, f.Close())
+
+	t.Logf("Open and Seek\n")
+	f, err = client.Open("hello.txt")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(f.Seek(6, io.SeekStart)).To(Equal(int64(6)))
+	bs, err = ioutil.ReadAll(f)
+	g.Expect(string(bs), err).To(Equal("World! Bye."))
+
+	buf := make([]byte, 5)
+	g.Expect(f.ReadAt(buf, 0)).To(Equal(5))
+	g.Expect(string(buf)).To(Equal("Hello"))
+
+	g.Expect(f.Seek(-4, io.SeekEnd)).To(Equal(int64(13)))
+	bs, err = ioutil.ReadAll(f)
+	g.Expect(string(bs), err).To(Equal("Bye."))
+
+	_, err = f.Write([]byte("x"))
+	g.Expect(err).To(HaveOccurred())
+	must(t, f.Close())
+
+	t.Logf("OpenFile O_TRUNC\n")
+	f, err = client.OpenFile("hello.txt", os.O_RDWR|os.O_TRUNC, 0644)
+	g.Expect(err).NotTo(HaveOccurred())
+	must(t, f.Close())
+
+	bs, err = client.ReadFile("hello.txt")
+	g.Expect(bs, err).To(BeEmpty())
+
+	t.Logf("Open directory\n")
+	must(t, client.Mkdir("dir", 0755))
+	must(t, client.WriteFile("dir/a", []byte("a"), 0644))
+	must(t, client.WriteFile("dir/b", []byte("b"), 0644))
+
+	f, err = client.Open("dir")
+	g.Expect(err).NotTo(HaveOccurred())
+	names, err := f.Readdirnames(0)
+	g.Expect(names, err).To(ConsistOf("a", "b"))
+	must(t, f.Close())
+}
+
+func TestFile_readAtEmpty(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	handler := &webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	}
+	var gets int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			gets++
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+	must(t, client.WriteFile("a.txt", []byte("hello"), 0644))
+
+	f, err := client.Open("a.txt")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer f.Close()
+
+	g.Expect(f.ReadAt([]byte{}, 2)).To(Equal(0))
+	g.Expect(gets).To(Equal(0))
+}
+
+func TestFile_readErrorOp(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	handler := &webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+	must(t, client.WriteFile("a.txt", []byte("hello"), 0644))
+
+	f, err := client.Open("a.txt")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer f.Close()
+
+	var se *gowebdav.StatusError
+
+	_, err = f.Read(make([]byte, 2))
+	g.Expect(errors.As(err, &se)).To(BeTrue())
+	g.Expect(se.Op).To(Equal("Read"))
+
+	_, err = f.(io.ReaderAt).ReadAt(make([]byte, 2), 1)
+	g.Expect(errors.As(err, &se)).To(BeTrue())
+	g.Expect(se.Op).To(Equal("ReadAt"))
+}
@@ -1,6 +1,7 @@
 package gowebdav
 
 import (
+	"encoding/xml"
 	"fmt"
 	"os"
 	"time"
@@ -14,9 +15,12 @@ type fileinfo struct {
 	name        string
 	contentType string
 	size        int64
+	hasSize     bool
 	modified    time.Time
+	created     time.Time
 	etag        string
 	isdir       bool
+	props       map[xml.Name]string
 }
 
 // Path returns the full path of a file
@@ -39,6 +43,12 @@ func (f fileinfo) Size() int64 {
 	return f.size
 }
 
+// HasSize reports whether the server gave the size of a file. If not, Size returns 0,
+// which should not be mistaken for an empty file. Collections have no size.
+func (f fileinfo) HasSize() bool {
+	return f.hasSize
+}
+
 // Mode will return the mode of a given file
 func (f fileinfo) Mode() os.FileMode {
 	// TODO check webdav perms
@@ -54,11 +64,25 @@ func (f fileinfo) ModTime() time.Time {
 	return f.modified
 }
 
+// CreationTime returns the creation time of a file, or the zero time if the
+// server did not provide it
+func (f fileinfo) CreationTime() time.Time {
+	return f.created
+}
+
 // ETag returns the ETag of a file
 func (f fileinfo) ETag() string {
 	return f.etag
 }
 
+// Props returns any properties the server sent besides the standard ones used
+// by this fileinfo, such as {http://owncloud.org/ns}fileid, keyed by name. Values
+// holding nested elements are given as their inner XML. Use PropFind to request
+// particular properties.
+func (f fileinfo) Props() map[xml.Name]string {
+	return f.props
+}
+
 // IsDir let us see if a given file is a directory or not
 func (f fileinfo) IsDir() bool {
 	return f.isdir
@@ -0,0 +1,189 @@
+package gowebdav
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"sort"
+)
+
+var (
+	_ fs.FS        = &FS{}
+	_ fs.ReadDirFS = &FS{}
+	_ fs.StatFS    = &FS{}
+)
+
+// FS adapts a Client to the io/fs interfaces, so that utilities such as fs.WalkDir
+// and fs.Glob can be used with a WebDAV server. As required by io/fs, names are
+// slash-separated, unrooted paths relative to the client's root, with "." being
+// the root itself. Missing resources give errors that match fs.ErrNotExist.
+type FS struct {
+	client Client
+}
+
+// NewFS wraps a client as an io/fs file system.
+func NewFS(c Client) *FS {
+	return &FS{client: c}
+}
+
+// Open opens the named file or directory. Files are read lazily using ReadStream.
+func (f *FS) Open(name string) (fs.File, error) {
+	info, err := f.Stat(name)
+	if err != nil {
+		return nil, withFSName("open", name, err)
+	}
+
+	if info.IsDir() {
+		return &fsDir{fsys: f, name: name, info: info}, nil
+	}
+	return &fsFile{fsys: f, name: name, info: info}, nil
+}
+
+// ReadDir reads the named directory, returning its entries sorted by name.
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	files, err := f.client.ReadDir(remotePath(name))
+	if err != nil {
+		return nil, withFSName("readdir", name, err)
+	}
+
+	entries := make([]fs.DirEntry, len(files))
+	for i, fi := range files {
+		entries[i] = dirEntry{fi}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+// Stat returns a FileInfo describing the named file.
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+
+	info, err := f.client.Stat(remotePath(name))
+	if err != nil {
+		return nil, withFSName("stat", name, err)
+	}
+	return info, nil
+}
+
+// remotePath converts an io/fs name to a rooted path.
+func remotePath(name string) string {
+	if name == "." {
+		return "/"
+	}
+	return "/" + name
+}
+
+// withFSName re-labels an error from the client so that it refers to the io/fs name.
+func withFSName(op, name string, err error) error {
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		err = pe.Err
+	}
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// fsFile is a remote file opened via FS; its content is fetched on the first Read.
+type fsFile struct {
+	fsys *FS
+	name string
+	info fs.FileInfo
+	rc   io.ReadCloser
+}
+
+func (f *fsFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *fsFile) Read(p []byte) (int, error) {
+	if f.rc == nil {
+		rc, err := f.fsys.client.ReadStream(remotePath(f.name))
+		if err != nil {
+			return 0, withFSName("read", f.name, err)
+		}
+		f.rc = rc
+	}
+	return f.rc.Read(p)
+}
+
+func (f *fsFile) Close() error {
+	if f.rc == nil {
+		return nil
+	}
+	return f.rc.Close()
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// fsDir is a remote collection opened via FS; its entries are listed on the first ReadDir.
+type fsDir struct {
+	fsys    *FS
+	name    string
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	listed  bool
+}
+
+func (d *fsDir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *fsDir) Read(_ []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errors.New("is a directory")}
+}
+
+func (d *fsDir) Close() error {
+	return nil
+}
+
+func (d *fsDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !d.listed {
+		entries, err := d.fsys.ReadDir(d.name)
+		if err != nil {
+			return nil, err
+		}
+		d.entries = entries
+		d.listed = true
+	}
+
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+
+	if n > len(d.entries) {
+		n = len(d.entries)
+	}
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// dirEntry adapts os.FileInfo to fs.DirEntry.
+type dirEntry struct {
+	fs.FileInfo
+}
+
+func (e dirEntry) Type() fs.FileMode {
+	return e.Mode().Type()
+}
+
+func (e dirEntry) Info() (fs.FileInfo, error) {
+	return e.FileInfo, nil
+}
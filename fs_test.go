@@ -0,0 +1,44 @@
+package gowebdav_test
+
+import (
+	"errors"
+	"io/fs"
+	"net/http/httptest"
+	"path"
+	"testing"
+	"testing/fstest"
+
+	. "github.com/onsi/gomega"
+	"github.com/rickb777/gowebdav"
+	"golang.org/x/net/webdav"
+)
+
+func TestFS(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	for _, f := range []string{"a.txt", "b/c.txt", "b/d/e.txt"} {
+		must(t, client.MkdirAll(path.Dir(f), 0755))
+		must(t, client.WriteFile(f, []byte("hello "+f), 0644))
+	}
+
+	fsys := gowebdav.NewFS(client)
+
+	must(t, fstest.TestFS(fsys, "a.txt", "b/c.txt", "b/d/e.txt"))
+
+	matches, err := fs.Glob(fsys, "b/*.txt")
+	g.Expect(matches, err).To(Equal([]string{"b/c.txt"}))
+
+	_, err = fsys.Open("missing.txt")
+	g.Expect(errors.Is(err, fs.ErrNotExist)).To(BeTrue())
+
+	_, err = fsys.Open("/a.txt")
+	g.Expect(errors.Is(err, fs.ErrInvalid)).To(BeTrue())
+}
@@ -0,0 +1,146 @@
+package gowebdav
+
+import (
+	"errors"
+	"os"
+	pathpkg "path"
+	"sort"
+	"strings"
+)
+
+// Glob returns the paths of all remote files and collections matching pattern,
+// in lexical order. Each path segment is matched using path.Match; in addition, a
+// segment "**" matches zero or more nested collections. For example
+//
+//	c.Glob("/photos/2024/*/IMG_*.jpg")
+//	c.Glob("/docs/**/*.pdf")
+//
+// Only collections that could match the pattern are listed, so literal leading
+// segments cost nothing. The only pattern error returned is path.ErrBadPattern;
+// paths that do not exist simply contribute no matches.
+func (c *client) Glob(pattern string) ([]string, error) {
+	paths, _, err := c.globMatches(pattern)
+	return paths, err
+}
+
+// GlobInfo is like Glob but returns the file information of each match, ordered
+// by path. This comes from the listing of its collection, so no extra requests
+// are needed, except when the pattern has no wildcards.
+func (c *client) GlobInfo(pattern string) ([]os.FileInfo, error) {
+	paths, found, err := c.globMatches(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]os.FileInfo, len(paths))
+	for i, p := range paths {
+		infos[i] = found[p]
+	}
+	return infos, nil
+}
+
+// globMatches finds the matches for pattern, returning their sorted paths and
+// their file information keyed by path.
+func (c *client) globMatches(pattern string) ([]string, map[string]os.FileInfo, error) {
+	var segments []string
+	if trimmed := strings.Trim(pathpkg.Clean(withLeadingSlash(pattern)), "/"); trimmed != "" {
+		segments = strings.Split(trimmed, "/")
+	}
+
+	for _, seg := range segments {
+		if _, err := pathpkg.Match(seg, ""); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	found := make(map[string]os.FileInfo)
+	if err := c.glob("/", segments, found); err != nil {
+		return nil, nil, err
+	}
+
+	paths := make([]string, 0, len(found))
+	for p := range found {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths, found, nil
+}
+
+// glob matches segments below dir. Leading literal segments are consumed without
+// any request; the first wildcard segment requires the collection to be listed.
+func (c *client) glob(dir string, segments []string, found map[string]os.FileInfo) error {
+	i := 0
+	for i < len(segments) && !hasMeta(segments[i]) {
+		i++
+	}
+
+	dir = pathpkg.Join(append([]string{dir}, segments[:i]...)...)
+
+	if i == len(segments) {
+		fi, err := c.Stat(dir)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		found[dir] = fi
+		return nil
+	}
+
+	files, err := c.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	return c.globIn(dir, files, segments[i:], found)
+}
+
+// globIn matches the first of segments against the entries of dir, which have already
+// been listed, and then continues matching the remaining segments further down.
+func (c *client) globIn(dir string, files []os.FileInfo, segments []string, found map[string]os.FileInfo) error {
+	seg, rest := segments[0], segments[1:]
+
+	if seg == "**" {
+		if len(rest) == 0 {
+			for _, f := range files {
+				found[pathpkg.Join(dir, f.Name())] = f
+			}
+		} else if err := c.globIn(dir, files, rest, found); err != nil {
+			// "**" matched zero collections
+			return err
+		}
+
+		for _, f := range files {
+			if f.IsDir() {
+				if err := c.glob(pathpkg.Join(dir, f.Name()), segments, found); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	for _, f := range files {
+		if matched, _ := pathpkg.Match(seg, f.Name()); matched {
+			p := pathpkg.Join(dir, f.Name())
+			if len(rest) == 0 {
+				found[p] = f
+			} else if f.IsDir() {
+				if err := c.glob(p, rest, found); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// hasMeta reports whether a path segment contains any of the magic characters
+// recognised by path.Match.
+func hasMeta(seg string) bool {
+	return strings.ContainsAny(seg, `*?[\`)
+}
@@ -0,0 +1,92 @@
+package gowebdav_test
+
+import (
+	"net/http/httptest"
+	"path"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/rickb777/gowebdav"
+	"golang.org/x/net/webdav"
+)
+
+func TestGlob(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	for _, f := range []string{
+		"photos/2024/jan/IMG_1.jpg",
+		"photos/2024/jan/notes.txt",
+		"photos/2024/feb/IMG_2.jpg",
+		"photos/2024/feb/deep/IMG_3.jpg",
+		"photos/2023/mar/IMG_4.jpg",
+	} {
+		must(t, client.MkdirAll(path.Dir(f), 0755))
+		must(t, client.WriteFile(f, []byte("x"), 0644))
+	}
+
+	cases := map[string][]string{
+		"/photos/2024/*/IMG_*.jpg": {"/photos/2024/feb/IMG_2.jpg", "/photos/2024/jan/IMG_1.jpg"},
+		"photos/20?3/*/*":          {"/photos/2023/mar/IMG_4.jpg"},
+		"/photos/**/IMG_?.jpg": {
+			"/photos/2023/mar/IMG_4.jpg",
+			"/photos/2024/feb/IMG_2.jpg",
+			"/photos/2024/feb/deep/IMG_3.jpg",
+			"/photos/2024/jan/IMG_1.jpg",
+		},
+		"/photos/2024/feb/**":        {"/photos/2024/feb/IMG_2.jpg", "/photos/2024/feb/deep", "/photos/2024/feb/deep/IMG_3.jpg"},
+		"/photos/2024/jan/notes.txt": {"/photos/2024/jan/notes.txt"},
+		"/photos/2025/*":             {},
+		"/nothing/here":              {},
+	}
+
+	for pattern, expected := range cases {
+		matches, err := client.Glob(pattern)
+		g.Expect(err).NotTo(HaveOccurred(), pattern)
+		g.Expect(matches).To(Equal(expected), pattern)
+	}
+
+	_, err := client.Glob("/photos/[")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestGlobInfo(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	must(t, client.MkdirAll("photos/sub", 0755))
+	must(t, client.WriteFile("photos/b.jpg", []byte("bb"), 0644))
+	must(t, client.WriteFile("photos/a.jpg", []byte("a"), 0644))
+	must(t, client.WriteFile("photos/c.txt", []byte("ccc"), 0644))
+
+	infos, err := client.GlobInfo("/photos/*.jpg")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(infos).To(HaveLen(2))
+	g.Expect(infos[0].Name()).To(Equal("a.jpg"))
+	g.Expect(infos[0].Size()).To(Equal(int64(1)))
+	g.Expect(infos[1].Name()).To(Equal("b.jpg"))
+	g.Expect(infos[1].Size()).To(Equal(int64(2)))
+
+	infos, err = client.GlobInfo("photos/sub")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(infos).To(HaveLen(1))
+	g.Expect(infos[0].IsDir()).To(BeTrue())
+
+	infos, err = client.GlobInfo("/photos/*.png")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(infos).To(BeEmpty())
+}
@@ -4,9 +4,11 @@ go 1.16
 
 require (
 	github.com/onsi/gomega v1.11.0
-	github.com/patrickmn/go-cache v2.1.0+incompatible // indirect
+	github.com/patrickmn/go-cache v2.1.0+incompatible
 	github.com/rickb777/httpclient v0.0.6
+	github.com/spf13/afero v1.6.0
 	golang.org/x/net v0.0.0-20210405180319-a5a99cb37ef4
+	golang.org/x/oauth2 v0.0.0-20210402161424-2e8d93401602
 )
 
 //replace github.com/rickb777/httpclient => ../httpclient
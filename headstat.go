@@ -0,0 +1,64 @@
+package gowebdav
+
+import (
+	"net/http"
+	pathpkg "path"
+	"strings"
+)
+
+// AllowHeadFallback lets Stat work with plain HTTP servers that do not support
+// WebDAV. If the server rejects PROPFIND with 405 Method Not Allowed (or 501 Not
+// Implemented), Stat instead uses a HEAD request and builds the file information
+// from the Content-Length, Last-Modified, Content-Type and ETag headers.
+//
+// A directory is recognised by a trailing slash on the path (or on the URL after
+// any redirect), or by an HTML directory index page for a path without a file
+// extension. This is a heuristic, so the option is off by default.
+func AllowHeadFallback() ClientOpt {
+	return func(c Client) {
+		c.(*client).headFallback = true
+	}
+}
+
+// useHeadFallback reports whether a failed PROPFIND should be followed by HEAD.
+func (c *client) useHeadFallback(err error) bool {
+	return c.headFallback && (hasStatus(err, http.StatusMethodNotAllowed) || hasStatus(err, http.StatusNotImplemented))
+}
+
+// statHead gets the file information using a HEAD request.
+func (c *client) statHead(path string) (*fileinfo, error) {
+	rs, err := c.request("Stat", http.MethodHead, withLeadingSlash(path), nil, nil)
+	if err != nil {
+		return nil, newPathErrorErr("Stat", path, err)
+	}
+	rs.Body.Close()
+
+	if rs.StatusCode < 200 || rs.StatusCode > 299 {
+		return nil, newPathError("Stat", path, rs.StatusCode)
+	}
+
+	lastModified := rs.Header.Get("Last-Modified")
+	fi := &fileinfo{
+		path:        path,
+		name:        pathpkg.Base(path),
+		contentType: rs.Header.Get("Content-Type"),
+		modified:    parseModified(&lastModified),
+		etag:        rs.Header.Get("ETag"),
+	}
+
+	finalPath := path
+	if rs.Request != nil {
+		finalPath = rs.Request.URL.Path
+	}
+
+	isHTML := strings.HasPrefix(fi.contentType, "text/html")
+	if strings.HasSuffix(path, "/") || strings.HasSuffix(finalPath, "/") || (isHTML && pathpkg.Ext(path) == "") {
+		fi.path = withTrailingSlash(path)
+		fi.isdir = true
+	} else if rs.ContentLength >= 0 {
+		fi.size = rs.ContentLength
+		fi.hasSize = true
+	}
+
+	return fi, nil
+}
@@ -0,0 +1,57 @@
+package gowebdav_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/rickb777/gowebdav"
+)
+
+func TestAllowHeadFallback(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	modified := time.Date(2021, 3, 1, 9, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodHead:
+			switch r.URL.Path {
+			case "/files/a.txt":
+				w.Header().Set("Content-Type", "text/plain")
+				w.Header().Set("Content-Length", "123")
+				w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+				w.Header().Set("ETag", `"abc"`)
+			case "/files":
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+	_, err := client.Stat("files/a.txt")
+	g.Expect(err).To(MatchError("PROPFIND /files/a.txt: 405"))
+
+	client = gowebdav.NewClient(server.URL, gowebdav.AllowHeadFallback())
+
+	fi, err := client.Stat("files/a.txt")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(fi.Name()).To(Equal("a.txt"))
+	g.Expect(fi.Size()).To(Equal(int64(123)))
+	g.Expect(fi.ModTime().Equal(modified)).To(BeTrue())
+	g.Expect(fi.IsDir()).To(BeFalse())
+	g.Expect(fi.(interface{ ETag() string }).ETag()).To(Equal(`"abc"`))
+
+	fi, err = client.Stat("files")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(fi.IsDir()).To(BeTrue())
+
+	_, err = client.Stat("files/missing.txt")
+	g.Expect(gowebdav.IsNotFound(err)).To(BeTrue())
+}
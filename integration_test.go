@@ -2,6 +2,7 @@ package gowebdav_test
 
 import (
 	"bytes"
+	"errors"
 	"github.com/rickb777/gowebdav/auth"
 	"github.com/rickb777/httpclient/logging"
 	"io"
@@ -10,6 +11,7 @@ import (
 	"os"
 	"sync"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	"github.com/rickb777/gowebdav"
@@ -35,7 +37,20 @@ func TestIntegration_digest_auth(t *testing.T) {
 }
 
 func TestIntegration_saml_auth(t *testing.T) {
-	testIntegration(t, auth.SAML("user1", "secret", "https://tenant123.sharepoint.com/sites/testsitealpha", nil))
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer server.Close()
+
+	// the tenant does not exist, so no token can be obtained and the request must
+	// fail rather than being sent without credentials
+	client := gowebdav.NewClient(server.URL,
+		gowebdav.SetAuthentication(auth.SAML("user1", "secret", "https://tenant123.sharepoint.com/sites/testsitealpha", nil)))
+
+	g.Expect(client.Ping()).To(HaveOccurred())
 }
 
 func testIntegration(t *testing.T, authenticator auth.Authenticator) {
@@ -152,6 +167,30 @@ func testIntegration(t *testing.T, authenticator auth.Authenticator) {
 	g.Expect("foo,tmp").To(ContainSubstring(fis[1].Name()))
 	g.Expect(fis[0].Name()).NotTo(Equal(fis[1].Name()))
 
+	t.Logf("CopyDepth foo foo-all infinity\n")
+	must(t, client.CopyDepth("foo", "foo-all", "infinity", false))
+	fis, err = client.ReadDir("foo-all")
+	g.Expect(fis, err).To(HaveLen(1))
+
+	t.Logf("CopyDepth foo foo-empty 0\n")
+	must(t, client.CopyDepth("foo", "foo-empty", "0", false))
+	fi3, err := client.Stat("foo-empty")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(fi3.IsDir()).To(BeTrue())
+	fis, err = client.ReadDir("foo-empty")
+	g.Expect(fis, err).To(HaveLen(0))
+
+	t.Logf("Rename file onto existing collection foo-all\n")
+	must(t, client.Copy("foo/LICENSE", "license-copy"))
+	must(t, client.Rename("license-copy", "foo-all"))
+	fi4, err := client.Stat("foo-all")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(fi4.IsDir()).To(BeFalse())
+
+	t.Logf("RemoveAll foo-all foo-empty\n")
+	must(t, client.RemoveAll("foo-all"))
+	must(t, client.RemoveAll("foo-empty"))
+
 	t.Logf("Remove tmp/other\n")
 	err = client.Remove("tmp/other")
 	g.Expect(err).NotTo(HaveOccurred())
@@ -164,6 +203,44 @@ func testIntegration(t *testing.T, authenticator auth.Authenticator) {
 	server.Close()
 }
 
+// memberLocks is a lock system in which the temporary locks that the handler
+// takes for each request also cover the members of a collection. So a locked
+// member prevents its collection being deleted, as RFC 4918 section 9.6.1
+// requires, which the x/net/webdav lock system alone does not do.
+type memberLocks struct {
+	webdav.LockSystem
+}
+
+func (ls memberLocks) Create(now time.Time, details webdav.LockDetails) (string, error) {
+	details.ZeroDepth = false
+	return ls.LockSystem.Create(now, details)
+}
+
+func TestIntegration_removeAllWithLockedMember(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: memberLocks{webdav.NewMemLS()},
+	})
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+	must(t, client.Mkdir("dir", 0755))
+	must(t, client.WriteFile("dir/a.txt", []byte("a"), 0644))
+	must(t, client.WriteFile("dir/b.txt", []byte("b"), 0644))
+	lockResource(t, server.URL+"/dir/a.txt")
+
+	err := client.RemoveAll("dir")
+	var se *gowebdav.StatusError
+	g.Expect(errors.As(err, &se)).To(BeTrue())
+	g.Expect(se.StatusCode).To(Equal(http.StatusLocked))
+	g.Expect(se.Path).To(Equal("/dir"))
+
+	g.Expect(client.Exists("dir/a.txt")).To(BeTrue())
+	g.Expect(client.Exists("dir/b.txt")).To(BeTrue())
+}
+
 func must(t *testing.T, err error) {
 	t.Helper()
 	NewGomegaWithT(t).Expect(err).NotTo(HaveOccurred())
@@ -0,0 +1,58 @@
+package gowebdav
+
+import (
+	"io"
+	"net/http"
+	"sync"
+)
+
+// SetMaxConcurrentRequests limits the number of requests that the client has in
+// flight at once to n, which avoids overwhelming the server when many goroutines
+// share the client. Further requests wait for a slot, or fail if their context is
+// done while waiting.
+//
+// A request holds its slot until its response body has been closed, so callers
+// must close the streams returned by ReadStream etc. promptly; a goroutine that
+// holds n open streams and then makes another request will wait forever.
+func SetMaxConcurrentRequests(n int) ClientOpt {
+	return func(c Client) {
+		if n > 0 {
+			c.(*client).limiter = make(chan struct{}, n)
+		}
+	}
+}
+
+// send sends the request, within the limit on concurrent requests if there is one.
+func (c *client) send(r *http.Request) (*http.Response, error) {
+	if c.limiter == nil {
+		return c.hc.Do(r)
+	}
+
+	select {
+	case c.limiter <- struct{}{}:
+	case <-r.Context().Done():
+		return nil, r.Context().Err()
+	}
+
+	res, err := c.hc.Do(r)
+	if err != nil {
+		<-c.limiter
+		return nil, err
+	}
+
+	res.Body = &releasingBody{ReadCloser: res.Body, release: func() { <-c.limiter }}
+	return res, nil
+}
+
+// releasingBody releases a request slot when it is closed.
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,57 @@
+package gowebdav_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/rickb777/gowebdav"
+)
+
+func TestSetMaxConcurrentRequests(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	mu := &sync.Mutex{}
+	inFlight, maxInFlight := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+		w.Write([]byte("Hello World"))
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL, gowebdav.SetMaxConcurrentRequests(3))
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			bs, err := client.ReadFile("a.txt")
+			g.Expect(string(bs), err).To(Equal("Hello World"))
+		}()
+	}
+	wg.Wait()
+
+	g.Expect(maxInFlight).To(BeNumerically("<=", 3))
+
+	// every slot has been released
+	for i := 0; i < 3; i++ {
+		rc, err := client.ReadStream("a.txt")
+		g.Expect(err).NotTo(HaveOccurred())
+		defer rc.Close()
+	}
+}
@@ -0,0 +1,292 @@
+package gowebdav
+
+import (
+	"encoding/xml"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MethodLock is the WebDAV method for locking and refreshing locks.
+const MethodLock = "LOCK"
+
+// WithLockToken submits a lock token with a mutating operation, in an If header
+// (RFC 4918 section 10.4), so that it can act on a resource locked with that
+// token. The token is usually a URI such as "opaquelocktoken:...". It may be
+// given more than once; the operation may then act on resources locked with any
+// of the tokens.
+func WithLockToken(token string) OpOpt {
+	return func(rq *http.Request) {
+		addIfList(rq, "", token)
+	}
+}
+
+// WithTaggedLockToken is like WithLockToken, but the token applies only to the
+// given resource, which is an absolute URL or an absolute path on the server,
+// including any base path. This is needed when the locked resource is not the
+// one being acted on, such as the destination of a move.
+func WithTaggedLockToken(resource, token string) OpOpt {
+	return func(rq *http.Request) {
+		addIfList(rq, resource, token)
+	}
+}
+
+// AddLockToken records a lock token held for path, such as one handed out by
+// another application. It is then submitted with every mutating operation on
+// path or, as the lock may be deep, on its members. This includes operations
+// such as Remove and Rename that do not take per-operation options.
+func (c *client) AddLockToken(path, token string) {
+	c.lockMutex.Lock()
+	defer c.lockMutex.Unlock()
+	if c.lockTokens == nil {
+		c.lockTokens = make(map[string]string)
+	}
+	c.lockTokens[withoutTrailingSlash(withLeadingSlash(path))] = token
+}
+
+// RemoveLockToken forgets the lock token recorded for path by AddLockToken.
+func (c *client) RemoveLockToken(path string) {
+	c.lockMutex.Lock()
+	defer c.lockMutex.Unlock()
+	delete(c.lockTokens, withoutTrailingSlash(withLeadingSlash(path)))
+}
+
+// submitLockTokens adds the recorded lock tokens that may apply to a request for
+// path, as tagged lists. These are the tokens for path, its destination if any,
+// and their parents, which must be unlocked to add or remove a member.
+func (c *client) submitLockTokens(rq *http.Request, path string) {
+	c.lockMutex.Lock()
+	defer c.lockMutex.Unlock()
+	if len(c.lockTokens) == 0 || !writeMethods[rq.Method] {
+		return
+	}
+
+	paths := []string{withLeadingSlash(path)}
+	if d := rq.Header.Get("Destination"); d != "" {
+		paths = append(paths, c.hrefPath(d))
+	}
+
+	locked := make([]string, 0, len(c.lockTokens))
+	for lp := range c.lockTokens {
+		for _, p := range paths {
+			if covers(lp, p) {
+				locked = append(locked, lp)
+				break
+			}
+		}
+	}
+	sort.Strings(locked)
+
+	for _, lp := range locked {
+		addIfList(rq, c.resourceURL(lp), c.lockTokens[lp])
+	}
+}
+
+// covers reports whether a lock on lockPath may affect an operation on p, either
+// because p is within lockPath or because lockPath is its parent.
+func covers(lockPath, p string) bool {
+	p = withoutTrailingSlash(p)
+	return lockPath == "" || p == lockPath ||
+		strings.HasPrefix(p, lockPath+"/") ||
+		withoutTrailingSlash(parentOf(p)) == lockPath
+}
+
+func parentOf(p string) string {
+	if i := strings.LastIndexByte(p, '/'); i >= 0 {
+		return p[:i]
+	}
+	return ""
+}
+
+// addIfList adds a list holding one lock token to the If header of a request. If
+// resource is not empty, the list is tagged with it. An If header cannot mix tagged
+// and untagged lists, so if it would, the untagged lists are tagged with the URL of
+// the request, to which they applied.
+func addIfList(rq *http.Request, resource, token string) {
+	list := "(<" + token + ">)"
+	existing := rq.Header.Get("If")
+	untagged := existing != "" && !strings.HasPrefix(existing, "<")
+
+	switch {
+	case existing == "":
+		if resource != "" {
+			list = "<" + resource + "> " + list
+		}
+	case resource == "" && untagged:
+		list = existing + " " + list
+	case resource == "":
+		list = existing + " <" + rq.URL.String() + "> " + list
+	case untagged:
+		list = "<" + rq.URL.String() + "> " + existing + " <" + resource + "> " + list
+	default:
+		list = existing + " <" + resource + "> " + list
+	}
+	rq.Header.Set("If", list)
+}
+
+// RefreshLock refreshes a lock held with token on path, so that it does not expire
+// during a long operation (RFC 4918 section 9.10.2). The timeout requested is in
+// seconds, or Infinite if it is negative; the server may choose another. The
+// timeout granted is returned, or -1 if it is Infinite. If the token is not that
+// of a current lock on path, the error wraps ErrPreconditionFailed.
+func (c *client) RefreshLock(path string, token string, timeoutSeconds int) (int, error) {
+	path = withLeadingSlash(path)
+	res, err := c.request("RefreshLock", MethodLock, path, nil, func(rq *http.Request) {
+		rq.Header.Set("If", "(<"+token+">)")
+		rq.Header.Set("Timeout", formatTimeout(timeoutSeconds))
+	})
+	if err != nil {
+		return 0, newPathErrorErr("RefreshLock", path, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return 0, responseError("RefreshLock", path, res)
+	}
+
+	if err = decompress(res); err != nil {
+		return 0, newPathErrorErr("RefreshLock", path, err)
+	}
+
+	var lp lockProp
+	decoder, err := xmlDecoder(res.Body, res.Header.Get("Content-Type"))
+	if err == nil {
+		err = decoder.Decode(&lp)
+	}
+	if err != nil {
+		return 0, newPathErrorErr("RefreshLock", path, err)
+	}
+
+	for _, l := range lp.LockDiscovery.ActiveLocks {
+		if l.LockToken.Href == token || len(lp.LockDiscovery.ActiveLocks) == 1 {
+			return parseTimeout(l.Timeout), nil
+		}
+	}
+	return timeoutSeconds, nil
+}
+
+// lockProp is the body of a response to LOCK.
+type lockProp struct {
+	XMLName       xml.Name      `xml:"DAV: prop"`
+	LockDiscovery lockDiscovery `xml:"DAV: lockdiscovery"`
+}
+
+type lockDiscovery struct {
+	ActiveLocks []activeLock `xml:"DAV: activelock"`
+}
+
+type activeLock struct {
+	LockType  xmlChoice `xml:"DAV: locktype"`
+	LockScope xmlChoice `xml:"DAV: lockscope"`
+	Depth     string    `xml:"DAV: depth"`
+	Owner     RawProp   `xml:"DAV: owner"`
+	Timeout   string    `xml:"DAV: timeout"`
+	LockToken struct {
+		Href string `xml:"DAV: href"`
+	} `xml:"DAV: locktoken"`
+	LockRoot struct {
+		Href string `xml:"DAV: href"`
+	} `xml:"DAV: lockroot"`
+}
+
+// xmlChoice is an element whose value is the name of its only child element,
+// such as <lockscope><exclusive/></lockscope>.
+type xmlChoice struct {
+	Value struct {
+		XMLName xml.Name
+	} `xml:",any"`
+}
+
+// LockInfo describes a lock that is active on a resource, as reported in its
+// {DAV:}lockdiscovery property.
+type LockInfo struct {
+	Type  string // normally "write"
+	Scope string // "exclusive" or "shared"
+	Depth string // "0" or "infinity"
+
+	// Owner is the owner given when the resource was locked, which is either text
+	// or XML, such as a href element.
+	Owner string
+
+	// Timeout is the number of seconds until the lock expires, or -1 if it never does.
+	Timeout int
+
+	// Token is the lock token, which is needed to act on the resource or to unlock it.
+	Token string
+
+	// Root is the path of the locked resource, which may be a collection above the
+	// resource that was asked about. It is empty if the server did not report it.
+	Root string
+}
+
+// ListLocks lists the locks that are active on path, from its {DAV:}lockdiscovery
+// property (RFC 4918 section 15.8). This helps to find out why the resource is
+// locked and who by. The list is empty if it is not locked.
+func (c *client) ListLocks(path string) ([]LockInfo, error) {
+	path = withLeadingSlash(path)
+	locks := make([]LockInfo, 0)
+
+	parse := func(resp interface{}) error {
+		r := resp.(*lockResponse)
+		for _, ps := range r.Propstats {
+			if !strings.Contains(ps.Status, responseStatusOK) {
+				continue
+			}
+			for _, l := range ps.Prop.LockDiscovery.ActiveLocks {
+				li := LockInfo{
+					Type:    l.LockType.Value.XMLName.Local,
+					Scope:   l.LockScope.Value.XMLName.Local,
+					Depth:   strings.TrimSpace(l.Depth),
+					Owner:   strings.TrimSpace(l.Owner.value()),
+					Timeout: parseTimeout(l.Timeout),
+					Token:   strings.TrimSpace(l.LockToken.Href),
+				}
+				if href := strings.TrimSpace(l.LockRoot.Href); href != "" {
+					li.Root = c.hrefPath(href)
+				}
+				locks = append(locks, li)
+			}
+		}
+		r.Propstats = nil
+		return nil
+	}
+
+	err := c.propfind("ListLocks", path, 0, propfindBody(c.davPrefix, []xml.Name{lockDiscoveryProperty}), &lockResponse{}, parse)
+	if err != nil {
+		return nil, withOp("ListLocks", path, err)
+	}
+	return locks, nil
+}
+
+var lockDiscoveryProperty = xml.Name{Space: "DAV:", Local: "lockdiscovery"}
+
+// lockResponse is a multistatus response element holding the lockdiscovery property.
+type lockResponse struct {
+	Propstats []struct {
+		Status string `xml:"DAV: status"`
+		Prop   struct {
+			LockDiscovery lockDiscovery `xml:"DAV: lockdiscovery"`
+		} `xml:"DAV: prop"`
+	} `xml:"DAV: propstat"`
+}
+
+// formatTimeout formats a Timeout header (RFC 4918 section 10.7).
+func formatTimeout(seconds int) string {
+	if seconds < 0 {
+		return "Infinite"
+	}
+	return "Second-" + strconv.Itoa(seconds)
+}
+
+// parseTimeout parses a timeout, such as "Second-60", in seconds. Infinite, or
+// anything not recognised, gives -1.
+func parseTimeout(s string) int {
+	s = strings.TrimSpace(s)
+	if len(s) > 7 && strings.EqualFold(s[:7], "Second-") {
+		if n, err := strconv.Atoi(s[7:]); err == nil {
+			return n
+		}
+	}
+	return -1
+}
@@ -0,0 +1,218 @@
+package gowebdav_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/rickb777/gowebdav"
+	"golang.org/x/net/webdav"
+)
+
+// lockResource locks a resource directly, as another application would, and
+// returns the lock token.
+func lockResource(t *testing.T, url string) string {
+	t.Helper()
+	body := `<?xml version="1.0" encoding="utf-8"?>
+		<d:lockinfo xmlns:d="DAV:">
+			<d:lockscope><d:exclusive/></d:lockscope>
+			<d:locktype><d:write/></d:locktype>
+			<d:owner>alice</d:owner>
+		</d:lockinfo>`
+	rq, _ := http.NewRequest("LOCK", url, strings.NewReader(body))
+	rq.Header.Set("Timeout", "Second-60")
+	res, err := http.DefaultClient.Do(rq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	token := strings.Trim(res.Header.Get("Lock-Token"), "<>")
+	if token == "" {
+		t.Fatalf("LOCK %s: %s", url, res.Status)
+	}
+	return token
+}
+
+func TestWithLockToken(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+	must(t, client.Mkdir("dir", 0755))
+	must(t, client.WriteFile("dir/a.txt", []byte("a"), 0644))
+	token := lockResource(t, server.URL+"/dir/a.txt")
+
+	err := client.WriteFile("dir/a.txt", []byte("b"), 0644)
+	var se *gowebdav.StatusError
+	g.Expect(errors.As(err, &se)).To(BeTrue())
+	g.Expect(se.StatusCode).To(Equal(http.StatusLocked))
+
+	g.Expect(client.WriteFile("dir/a.txt", []byte("b"), 0644, gowebdav.WithLockToken(token))).To(Succeed())
+	g.Expect(client.Copy("dir/a.txt", "dir/b.txt")).To(Succeed())
+
+	// a destination that is locked needs a tagged token
+	token = lockResource(t, server.URL+"/dir/b.txt")
+	g.Expect(client.Copy("dir/a.txt", "dir/b.txt")).NotTo(Succeed())
+	g.Expect(client.Copy("dir/a.txt", "dir/b.txt", gowebdav.WithTaggedLockToken(server.URL+"/dir/b.txt", token))).To(Succeed())
+}
+
+func TestAddLockToken(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+	must(t, client.Mkdir("dir", 0755))
+	must(t, client.WriteFile("dir/a.txt", []byte("a"), 0644))
+	must(t, client.WriteFile("other.txt", []byte("o"), 0644))
+	token := lockResource(t, server.URL+"/dir/")
+
+	g.Expect(client.Remove("dir/a.txt")).NotTo(Succeed())
+
+	client.AddLockToken("dir", token)
+	g.Expect(client.Remove("dir/a.txt")).To(Succeed())
+	g.Expect(client.Rename("other.txt", "dir/other.txt")).To(Succeed())
+	g.Expect(client.WriteFile("elsewhere.txt", []byte("e"), 0644)).To(Succeed())
+
+	client.RemoveLockToken("dir/")
+	g.Expect(client.WriteFile("dir/c.txt", []byte("c"), 0644)).NotTo(Succeed())
+}
+
+func TestWithLockToken_ifHeader(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var ifHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ifHeader = r.Header.Get("If")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	must(t, client.WriteFile("a.txt", nil, 0644, gowebdav.WithLockToken("t1"), gowebdav.WithLockToken("t2")))
+	g.Expect(ifHeader).To(Equal("(<t1>) (<t2>)"))
+
+	must(t, client.WriteFile("a.txt", nil, 0644, gowebdav.WithTaggedLockToken("/b.txt", "t2")))
+	g.Expect(ifHeader).To(Equal("</b.txt> (<t2>)"))
+
+	must(t, client.WriteFile("a.txt", nil, 0644, gowebdav.WithLockToken("t1"), gowebdav.WithTaggedLockToken("/b.txt", "t2")))
+	g.Expect(ifHeader).To(Equal("<" + server.URL + "/a.txt> (<t1>) </b.txt> (<t2>)"))
+}
+
+func TestRefreshLock(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+	must(t, client.WriteFile("a.txt", []byte("a"), 0644))
+	token := lockResource(t, server.URL+"/a.txt")
+
+	timeout, err := client.RefreshLock("a.txt", token, 3600)
+	g.Expect(timeout, err).To(Equal(3600))
+
+	_, err = client.RefreshLock("a.txt", "opaquelocktoken:unknown", 3600)
+	g.Expect(errors.Is(err, gowebdav.ErrPreconditionFailed)).To(BeTrue())
+}
+
+func TestRefreshLock_infinite(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var timeoutHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeoutHeader = r.Header.Get("Timeout")
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+			<d:prop xmlns:d="DAV:"><d:lockdiscovery>
+				<d:activelock>
+					<d:timeout>Second-60</d:timeout>
+					<d:locktoken><d:href>opaquelocktoken:other</d:href></d:locktoken>
+				</d:activelock>
+				<d:activelock>
+					<d:timeout>Infinite</d:timeout>
+					<d:locktoken><d:href>opaquelocktoken:mine</d:href></d:locktoken>
+				</d:activelock>
+			</d:lockdiscovery></d:prop>`))
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	timeout, err := client.RefreshLock("a.txt", "opaquelocktoken:mine", -1)
+	g.Expect(timeout, err).To(Equal(-1))
+	g.Expect(timeoutHeader).To(Equal("Infinite"))
+}
+
+func TestListLocks(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(http.StatusMultiStatus)
+		w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+			<d:multistatus xmlns:d="DAV:"><d:response>
+				<d:href>/dav/dir/a.txt</d:href>
+				<d:propstat><d:prop><d:lockdiscovery>
+					<d:activelock>
+						<d:locktype><d:write/></d:locktype>
+						<d:lockscope><d:exclusive/></d:lockscope>
+						<d:depth>infinity</d:depth>
+						<d:owner><d:href>mailto:alice@example.com</d:href></d:owner>
+						<d:timeout>Second-3600</d:timeout>
+						<d:locktoken><d:href>opaquelocktoken:e71d4fae</d:href></d:locktoken>
+						<d:lockroot><d:href>/dav/dir/</d:href></d:lockroot>
+					</d:activelock>
+					<d:activelock>
+						<d:locktype><d:write/></d:locktype>
+						<d:lockscope><d:shared/></d:lockscope>
+						<d:depth>0</d:depth>
+						<d:owner>bob</d:owner>
+						<d:timeout>Infinite</d:timeout>
+						<d:locktoken><d:href>opaquelocktoken:a515cfa4</d:href></d:locktoken>
+					</d:activelock>
+				</d:lockdiscovery></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat>
+			</d:response></d:multistatus>`))
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL + "/dav")
+
+	locks, err := client.ListLocks("dir/a.txt")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(locks).To(Equal([]gowebdav.LockInfo{
+		{
+			Type:    "write",
+			Scope:   "exclusive",
+			Depth:   "infinity",
+			Owner:   "<d:href>mailto:alice@example.com</d:href>",
+			Timeout: 3600,
+			Token:   "opaquelocktoken:e71d4fae",
+			Root:    "/dir/",
+		},
+		{
+			Type:    "write",
+			Scope:   "shared",
+			Depth:   "0",
+			Owner:   "bob",
+			Timeout: -1,
+			Token:   "opaquelocktoken:a515cfa4",
+		},
+	}))
+}
@@ -9,6 +9,9 @@ import (
 
 // ReadConfig reads login and password configuration from ~/.netrc
 // machine foo.com login username password 123456
+//
+// The machine is matched against the host of uri, with or without its port;
+// failing that, any "default" entry is used.
 func ReadConfig(uri, netrc string) (string, string) {
 	u, err := url.Parse(uri)
 	if err != nil {
@@ -32,46 +35,61 @@ func parseConfig(file io.Reader, u *url.URL) (string, string) {
 	// "password" name
 	// (others are ignored here)
 	//
+	// or the single word "default", which introduces an entry that matches
+	// any machine.
+	//
 	// The separating whitespace can optionally include newlines.
 	// The order of the nouns is normally "machine" then "login" then
 	// "password", but we allow "login" and "password" to be swapped.
+	//
+	// The best match is a machine that includes the port given in the URL,
+	// then a machine without the port, and finally the default entry.
 
-	var machine, login, password string
+	var entries []entry
 	scanner := bufio.NewScanner(file)
 	scanner.Split(bufio.ScanWords)
-	inDefault := false
 
 	for scanner.Scan() {
 		id := ""
 		noun := scanner.Text()
 		if noun == "default" {
-			inDefault = true
+			entries = append(entries, entry{isDefault: true})
+			continue
 		} else if scanner.Scan() {
 			id = scanner.Text()
 		}
 
 		switch noun {
 		case "machine":
-			inDefault = false
-			if id == u.Host {
-				machine = id
-				login = ""
-				password = ""
-			}
+			entries = append(entries, entry{machine: id})
 		case "login":
-			if inDefault || machine == u.Host {
-				login = id
+			if len(entries) > 0 {
+				entries[len(entries)-1].login = id
 			}
 		case "password":
-			if inDefault || machine == u.Host {
-				password = id
+			if len(entries) > 0 {
+				entries[len(entries)-1].password = id
 			}
 		}
+	}
 
-		if machine == u.Host && login != "" && password != "" {
-			return login, password
+	for _, matches := range []func(entry) bool{
+		func(e entry) bool { return !e.isDefault && e.machine == u.Host },
+		func(e entry) bool { return !e.isDefault && e.machine == u.Hostname() },
+		func(e entry) bool { return e.isDefault },
+	} {
+		for _, e := range entries {
+			if matches(e) {
+				return e.login, e.password
+			}
 		}
 	}
 
-	return login, password
+	return "", ""
+}
+
+type entry struct {
+	machine         string
+	isDefault       bool
+	login, password string
 }
@@ -32,6 +32,14 @@ func TestParseConfig(t *testing.T) {
 			  password secret
 			  account acct`,
 
+		// match machine without the port
+		"delta|secret": `machine other.server.com login xyz password xyz123
+			machine my.server.com login delta password secret`,
+
+		// match default given after the machines
+		"epsilon|secret": `machine other.server.com login xyz password xyz123
+			default login epsilon password secret`,
+
 		// ignore machine and match default
 		"gamma|secret": `default login gamma
 			  password secret
@@ -47,3 +55,15 @@ func TestParseConfig(t *testing.T) {
 		g.Expect(p).To(Equal(exp[1]))
 	}
 }
+
+func TestParseConfig_withoutPort(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	u, _ := url.Parse("https://my.server.com/remote.php/webdav")
+	input := `machine my.server.com:444 login alpha password secret1
+		machine my.server.com login beta password secret2`
+
+	l, p := parseConfig(strings.NewReader(input), u)
+	g.Expect(l).To(Equal("beta"))
+	g.Expect(p).To(Equal("secret2"))
+}
@@ -0,0 +1,33 @@
+package gowebdav
+
+import (
+	"net/http"
+	"time"
+)
+
+// SetObserver sets a function that is called after each HTTP exchange with the
+// server, for example to record metrics. It receives the name of the client
+// operation that made the request, such as "ReadDir", "WriteStream" or "Copy";
+// the path; the response status, or 0 if there was no response; the time taken
+// until the response headers were received; and any error.
+//
+// An operation may make several requests, for example when it is redirected or
+// must authenticate, and each is observed separately. The observer may be called
+// concurrently, so it must be safe for that.
+func SetObserver(fn func(op string, path string, status int, dur time.Duration, err error)) ClientOpt {
+	return func(c Client) {
+		c.(*client).observer = fn
+	}
+}
+
+func (c *client) observe(op, path string, res *http.Response, dur time.Duration, err error) {
+	if c.observer == nil {
+		return
+	}
+
+	status := 0
+	if res != nil {
+		status = res.StatusCode
+	}
+	c.observer(op, path, status, dur, err)
+}
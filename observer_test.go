@@ -0,0 +1,49 @@
+package gowebdav_test
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/rickb777/gowebdav"
+	"golang.org/x/net/webdav"
+)
+
+func TestSetObserver(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer server.Close()
+
+	var mu sync.Mutex
+	var observed []string
+	client := gowebdav.NewClient(server.URL, gowebdav.SetObserver(
+		func(op string, path string, status int, dur time.Duration, err error) {
+			g.Expect(dur).To(BeNumerically(">=", 0))
+			mu.Lock()
+			observed = append(observed, fmt.Sprintf("%s %s %d %v", op, path, status, err))
+			mu.Unlock()
+		}))
+
+	must(t, client.Mkdir("dir", 0755))
+	must(t, client.WriteFile("dir/a.txt", []byte("hello"), 0644))
+	must(t, client.Copy("dir/a.txt", "dir/b.txt"))
+	_, err := client.ReadDir("dir")
+	g.Expect(err).NotTo(HaveOccurred())
+	_, err = client.ReadFile("dir/missing.txt")
+	g.Expect(err).To(HaveOccurred())
+
+	g.Expect(observed).To(Equal([]string{
+		"Mkdir /dir/ 201 <nil>",
+		"WriteFile /dir/a.txt 201 <nil>",
+		"Copy /dir/a.txt 201 <nil>",
+		"ReadDir /dir/ 207 <nil>",
+		"ReadStream /dir/missing.txt 404 <nil>",
+	}))
+}
@@ -0,0 +1,109 @@
+package gowebdav
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// WriteStreamAt overwrites length bytes of an existing file, starting at offset,
+// with data read from stream. This avoids uploading the whole of a large file
+// to change part of it. It uses the partial update protocol of sabre/dav, which
+// is also used by Nextcloud and ownCloud: a PATCH request with an X-Update-Range
+// header.
+//
+// The server's support is checked first with an OPTIONS request; if it does not
+// allow PATCH, the error wraps ErrPartialUpdateNotSupported.
+func (c *client) WriteStreamAt(path string, stream io.Reader, offset int64, length int64) error {
+	path = withLeadingSlash(path)
+	if offset < 0 || length <= 0 {
+		return newPathErrorErr("WriteStreamAt", path, fmt.Errorf("invalid range %d+%d", offset, length))
+	}
+
+	_, allowed, err := c.Capabilities(path)
+	if err != nil {
+		return err
+	}
+	if !containsFold(allowed, http.MethodPatch) {
+		return newPathErrorErr("WriteStreamAt", path, ErrPartialUpdateNotSupported)
+	}
+
+	c.InvalidateCache(path)
+	res, err := c.request("WriteStreamAt", http.MethodPatch, path, io.LimitReader(stream, length), func(rq *http.Request) {
+		rq.ContentLength = length
+		rq.Header.Set("Content-Type", "application/x-sabredav-partialupdate")
+		rq.Header.Set("X-Update-Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	})
+	if err != nil {
+		return newPathErrorErr("WriteStreamAt", path, err)
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	}
+	return responseError("WriteStreamAt", path, res)
+}
+
+// ResumableUpload uploads size bytes read from r to path, resuming an earlier
+// upload that was interrupted. It first sends HEAD to learn how many bytes of the
+// file the server already has, then sends only the rest in a PUT with a
+// Content-Range header, such as "bytes 1000-1999/2000". If the server has none
+// of the file, or the whole of it, or the size is unknown, the whole file is sent
+// with an ordinary PUT, as it is if the server rejects the ranged PUT.
+//
+// Servers are not required to support ranged PUT and RFC 7231 says that they should
+// reject it, so this only helps with those that are configured to accept it, such
+// as Apache mod_dav. In case a server wrongly ignores the Content-Range header and
+// stores only the rest of the file, the size is checked with HEAD afterwards and
+// the whole file is sent if it is wrong. The ranged PUT is made conditional on the
+// ETag from the first HEAD, so that it does not extend a file that has changed
+// since. The file is assumed to be complete if its size matches, without checking
+// its content.
+func (c *client) ResumableUpload(path string, r io.ReaderAt, size int64) error {
+	path = withLeadingSlash(path)
+
+	stored, _, etag, _, err := c.Head(path)
+	switch {
+	case IsNotFound(err):
+		stored = 0
+	case err != nil:
+		return err
+	}
+
+	if stored == size {
+		return nil
+	}
+
+	if stored > 0 && stored < size && c.putRange(path, r, stored, size, etag) {
+		if got, _, _, _, err := c.Head(path); err == nil && got == size {
+			return nil
+		}
+	}
+
+	return c.WriteStreamSized(path, io.NewSectionReader(r, 0, size), size, "")
+}
+
+// putRange sends the bytes of r from start to the end in a ranged PUT, reporting
+// whether the server accepted them.
+func (c *client) putRange(path string, r io.ReaderAt, start, size int64, etag string) bool {
+	_, err := c.put("ResumableUpload", path, io.NewSectionReader(r, start, size-start), func(rq *http.Request) {
+		rq.ContentLength = size - start
+		rq.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, size-1, size))
+		if etag != "" {
+			rq.Header.Set("If-Match", etag)
+		}
+	})
+	return err == nil
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
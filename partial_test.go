@@ -0,0 +1,122 @@
+package gowebdav_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/rickb777/gowebdav"
+	"golang.org/x/net/webdav"
+)
+
+func TestWriteStreamAt(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	content := []byte("hello world")
+	allow := "OPTIONS, GET, PUT, PATCH"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodOptions:
+			w.Header().Set("Allow", allow)
+		case http.MethodPatch:
+			g.Expect(r.Header.Get("Content-Type")).To(Equal("application/x-sabredav-partialupdate"))
+			var from, to int
+			_, err := fmt.Sscanf(r.Header.Get("X-Update-Range"), "bytes=%d-%d", &from, &to)
+			g.Expect(err).NotTo(HaveOccurred())
+			body, _ := ioutil.ReadAll(r.Body)
+			g.Expect(body).To(HaveLen(to - from + 1))
+			copy(content[from:], body)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	must(t, client.WriteStreamAt("a.txt", strings.NewReader("WORLD and more"), 6, 5))
+	g.Expect(string(content)).To(Equal("hello WORLD"))
+
+	g.Expect(client.WriteStreamAt("a.txt", strings.NewReader("x"), -1, 1)).To(MatchError(ContainSubstring("invalid range")))
+
+	allow = "OPTIONS, GET, PUT"
+	err := client.WriteStreamAt("a.txt", strings.NewReader("HELLO"), 0, 5)
+	g.Expect(errors.Is(err, gowebdav.ErrPartialUpdateNotSupported)).To(BeTrue())
+	g.Expect(string(content)).To(Equal("hello WORLD"))
+}
+
+func TestResumableUpload(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// this server supports ranged PUT, as Apache mod_dav can be configured to
+	stored := []byte("0123")
+	var puts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", fmt.Sprintf(`"%d"`, len(stored)))
+		switch r.Method {
+		case http.MethodHead:
+			w.Header().Set("Content-Length", fmt.Sprint(len(stored)))
+		case http.MethodPut:
+			body, _ := ioutil.ReadAll(r.Body)
+			cr := r.Header.Get("Content-Range")
+			puts = append(puts, cr+" "+r.Header.Get("If-Match")+" "+string(body))
+			var start, end, total int
+			if _, err := fmt.Sscanf(cr, "bytes %d-%d/%d", &start, &end, &total); err == nil {
+				stored = append(stored[:start], body...)
+			} else {
+				stored = body
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+	data := strings.NewReader("0123456789")
+
+	g.Expect(client.ResumableUpload("a.bin", data, 10)).To(Succeed())
+	g.Expect(string(stored)).To(Equal("0123456789"))
+	g.Expect(puts).To(Equal([]string{`bytes 4-9/10 "4" 456789`}))
+
+	// nothing more to send
+	puts = nil
+	g.Expect(client.ResumableUpload("a.bin", data, 10)).To(Succeed())
+	g.Expect(puts).To(BeEmpty())
+}
+
+func TestResumableUpload_unsupported(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// this server ignores Content-Range, so it would store only the rest of the file
+	var puts []string
+	handler := &webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			puts = append(puts, r.Header.Get("Content-Range"))
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+	must(t, client.WriteFile("a.bin", []byte("0123"), 0644))
+	puts = nil
+
+	g.Expect(client.ResumableUpload("a.bin", strings.NewReader("0123456789"), 10)).To(Succeed())
+	g.Expect(puts).To(Equal([]string{"bytes 4-9/10", ""}))
+
+	bs, err := client.ReadFile("a.bin")
+	g.Expect(string(bs), err).To(Equal("0123456789"))
+
+	// a new upload is sent whole
+	puts = nil
+	g.Expect(client.ResumableUpload("b.bin", strings.NewReader("xyz"), 3)).To(Succeed())
+	g.Expect(puts).To(Equal([]string{""}))
+}
@@ -0,0 +1,70 @@
+package gowebdav
+
+import (
+	"io"
+	"os"
+)
+
+// progressInterval is the number of bytes between progress callbacks.
+const progressInterval = 32 * 1024
+
+// WriteStreamWithProgress is like WriteStream but calls fn as the stream is
+// uploaded, with the number of bytes sent so far. This happens about every
+// 32KiB and once more with the total when the stream is exhausted.
+func (c *client) WriteStreamWithProgress(path string, stream io.Reader, perm os.FileMode, fn func(bytesSoFar int64), opts ...OpOpt) error {
+	return c.WriteStream(path, &progressReader{r: stream, fn: fn}, perm, opts...)
+}
+
+// ReadStreamWithProgress is like ReadStream but calls fn as the stream is
+// read, with the number of bytes received so far. This happens about every
+// 32KiB and once more with the total when the end of the stream is reached
+// or it is closed.
+func (c *client) ReadStreamWithProgress(path string, fn func(bytesSoFar int64)) (io.ReadCloser, error) {
+	rc, err := c.ReadStream(path)
+	if err != nil {
+		return nil, err
+	}
+	return &progressReadCloser{progressReader: progressReader{r: rc, fn: fn}, c: rc}, nil
+}
+
+// progressReader counts the bytes read through it and reports them periodically.
+type progressReader struct {
+	r        io.Reader
+	fn       func(int64)
+	n        int64
+	reported int64
+	done     bool
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.n += int64(n)
+
+	if err == io.EOF {
+		p.finish()
+	} else if p.n-p.reported >= progressInterval {
+		p.reported = p.n
+		p.fn(p.n)
+	}
+
+	return n, err
+}
+
+// finish makes the final report of the total, once only.
+func (p *progressReader) finish() {
+	if !p.done {
+		p.done = true
+		p.reported = p.n
+		p.fn(p.n)
+	}
+}
+
+type progressReadCloser struct {
+	progressReader
+	c io.Closer
+}
+
+func (p *progressReadCloser) Close() error {
+	p.finish()
+	return p.c.Close()
+}
@@ -0,0 +1,46 @@
+package gowebdav_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/rickb777/gowebdav"
+	"golang.org/x/net/webdav"
+)
+
+func TestProgress(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	content := bytes.Repeat([]byte("0123456789abcdef"), 10000) // 160000 bytes
+
+	var sent []int64
+	err := client.WriteStreamWithProgress("big.bin", bytes.NewReader(content), 0644, func(n int64) {
+		sent = append(sent, n)
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(len(sent)).To(BeNumerically(">=", 4))
+	g.Expect(len(sent)).To(BeNumerically("<", 10))
+	g.Expect(sent[len(sent)-1]).To(Equal(int64(len(content))))
+
+	var received []int64
+	rc, err := client.ReadStreamWithProgress("big.bin", func(n int64) {
+		received = append(received, n)
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	bs, err := ioutil.ReadAll(rc)
+	g.Expect(bs, err).To(Equal(content))
+	must(t, rc.Close())
+	g.Expect(len(received)).To(BeNumerically(">", 1))
+	g.Expect(received[len(received)-1]).To(Equal(int64(len(content))))
+}
@@ -0,0 +1,144 @@
+package gowebdav
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// anyResponse is a multistatus response element holding arbitrary properties.
+type anyResponse struct {
+	Href      string        `xml:"DAV: href"`
+	Propstats []anyPropstat `xml:"DAV: propstat"`
+}
+
+type anyPropstat struct {
+	Status string `xml:"DAV: status"`
+	Prop   struct {
+		Values []RawProp `xml:",any"`
+	} `xml:"DAV: prop"`
+}
+
+// RawProp is a property element as returned by the server, such as
+// {http://owncloud.org/ns}fileid, that the client has no particular use for.
+type RawProp struct {
+	XMLName xml.Name
+	Text    string `xml:",chardata"`
+	Inner   string `xml:",innerxml"`
+}
+
+// value is the text of the property, or its inner XML if it has nested elements.
+func (p RawProp) value() string {
+	if strings.Contains(p.Inner, "<") {
+		return p.Inner
+	}
+	return p.Text
+}
+
+// extraProps maps the names of the unrecognised properties to their values.
+func extraProps(extra []RawProp) map[xml.Name]string {
+	if len(extra) == 0 {
+		return nil
+	}
+	m := make(map[xml.Name]string, len(extra))
+	for _, p := range extra {
+		m[p.XMLName] = p.value()
+	}
+	return m
+}
+
+// propfindBody builds a PROPFIND request body for the named properties, using
+// davPrefix for the DAV: namespace. With no names, all properties are requested.
+func propfindBody(davPrefix string, props []xml.Name) string {
+	ns := newNamespaces(davPrefix)
+	b := &strings.Builder{}
+
+	if len(props) == 0 {
+		return ns.wrap("propfind", ns.element("DAV:", "allprop", ""))
+	}
+
+	for _, p := range props {
+		b.WriteString(ns.element(p.Space, p.Local, ""))
+	}
+	return ns.wrap("propfind", ns.element("DAV:", "prop", b.String()))
+}
+
+// namespaces allocates the prefixes used in a generated request body. The DAV:
+// namespace has the client's DAV prefix and each other namespace gets the first
+// of ns1, ns2 and so on that is not already taken, so no two namespaces collide.
+type namespaces struct {
+	prefixes map[string]string
+	taken    map[string]bool
+	decls    strings.Builder
+}
+
+func newNamespaces(davPrefix string) *namespaces {
+	ns := &namespaces{
+		prefixes: make(map[string]string),
+		taken:    make(map[string]bool),
+	}
+	ns.declare("DAV:", davPrefix)
+	return ns
+}
+
+func (ns *namespaces) declare(space, prefix string) {
+	ns.prefixes[space] = prefix
+	ns.taken[prefix] = true
+	if prefix == "" {
+		fmt.Fprintf(&ns.decls, ` xmlns="%s"`, escapeXML(space))
+	} else {
+		fmt.Fprintf(&ns.decls, ` xmlns:%s="%s"`, prefix, escapeXML(space))
+	}
+}
+
+// prefix gets the prefix for a namespace, allocating one if necessary.
+func (ns *namespaces) prefix(space string) string {
+	if prefix, exists := ns.prefixes[space]; exists {
+		return prefix
+	}
+	for i := 1; ; i++ {
+		prefix := fmt.Sprintf("ns%d", i)
+		if !ns.taken[prefix] {
+			ns.declare(space, prefix)
+			return prefix
+		}
+	}
+}
+
+// name gets the qualified name of an element in the given namespace.
+func (ns *namespaces) name(space, local string) string {
+	if prefix := ns.prefix(space); prefix != "" {
+		return prefix + ":" + local
+	}
+	return local
+}
+
+// element formats an element with the given content, which is not escaped. With
+// no content, it is an empty-element tag.
+func (ns *namespaces) element(space, local, content string) string {
+	start, end := local, local
+	if space == "" {
+		start += ` xmlns=""` // in case DAV: is the default namespace
+	} else {
+		start = ns.name(space, local)
+		end = start
+	}
+	if content == "" {
+		return "<" + start + "/>"
+	}
+	return "<" + start + ">" + content + "</" + end + ">"
+}
+
+// wrap encloses content in the named DAV: root element, which declares all the
+// namespaces that have been allocated.
+func (ns *namespaces) wrap(root, content string) string {
+	name := ns.name("DAV:", root)
+	return "<" + name + ns.decls.String() + ">" + content + "</" + name + ">"
+}
+
+// escapeXML escapes s for use as XML character data or an attribute value.
+func escapeXML(s string) string {
+	b := &strings.Builder{}
+	_ = xml.EscapeText(b, []byte(s))
+	return b.String()
+}
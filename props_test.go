@@ -0,0 +1,204 @@
+package gowebdav_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/rickb777/gowebdav"
+	"golang.org/x/net/webdav"
+)
+
+func TestPropFind(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.Method).To(Equal("PROPFIND"))
+		g.Expect(r.Header.Get("Depth")).To(Equal("infinity"))
+
+		body, _ := ioutil.ReadAll(r.Body)
+		g.Expect(string(body)).To(Equal(`<d:propfind xmlns:d="DAV:" xmlns:ns1="http://owncloud.org/ns"><d:prop>` +
+			`<ns1:fileid/><ns1:permissions/><d:resourcetype/></d:prop></d:propfind>`))
+
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprint(w, `<?xml version="1.0"?>
+			<d:multistatus xmlns:d="DAV:" xmlns:oc="http://owncloud.org/ns">
+				<d:response>
+					<d:href>/files/</d:href>
+					<d:propstat>
+						<d:prop>
+							<oc:fileid>100</oc:fileid>
+							<oc:permissions>RDNVCK</oc:permissions>
+							<d:resourcetype><d:collection/></d:resourcetype>
+						</d:prop>
+						<d:status>HTTP/1.1 200 OK</d:status>
+					</d:propstat>
+				</d:response>
+				<d:response>
+					<d:href>/files/a.txt</d:href>
+					<d:propstat>
+						<d:prop>
+							<oc:fileid>101</oc:fileid>
+							<d:resourcetype/>
+						</d:prop>
+						<d:status>HTTP/1.1 200 OK</d:status>
+					</d:propstat>
+					<d:propstat>
+						<d:prop>
+							<oc:permissions/>
+						</d:prop>
+						<d:status>HTTP/1.1 404 Not Found</d:status>
+					</d:propstat>
+				</d:response>
+			</d:multistatus>`)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	fileID := xml.Name{Space: "http://owncloud.org/ns", Local: "fileid"}
+	permissions := xml.Name{Space: "http://owncloud.org/ns", Local: "permissions"}
+	resourceType := xml.Name{Space: "DAV:", Local: "resourcetype"}
+
+	result, err := client.PropFind("files", gowebdav.DepthInfinity, []xml.Name{fileID, permissions, resourceType})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result).To(HaveLen(2))
+	g.Expect(result["/files/"]).To(Equal(map[xml.Name]string{
+		fileID:       "100",
+		permissions:  "RDNVCK",
+		resourceType: "<d:collection/>",
+	}))
+	g.Expect(result["/files/a.txt"]).To(Equal(map[xml.Name]string{
+		fileID:       "101",
+		resourceType: "",
+	}))
+}
+
+func TestGetProperty(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+	must(t, client.WriteFile("a.txt", []byte("hello"), 0644))
+
+	value, found, err := client.GetProperty("a.txt", xml.Name{Space: "DAV:", Local: "getcontentlength"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(found).To(BeTrue())
+	g.Expect(value).To(Equal("5"))
+
+	value, found, err = client.GetProperty("a.txt", xml.Name{Space: "http://example.com/ns", Local: "vendor-id"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(found).To(BeFalse())
+	g.Expect(value).To(BeEmpty())
+
+	_, _, err = client.GetProperty("missing.txt", xml.Name{Space: "DAV:", Local: "getcontentlength"})
+	g.Expect(errors.Is(err, os.ErrNotExist)).To(BeTrue())
+}
+
+func TestPropFindRaw(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+	must(t, client.WriteFile("a.txt", []byte("hello"), 0644))
+
+	body := `<d:propfind xmlns:d="DAV:"><d:prop><d:getcontentlength/></d:prop></d:propfind>`
+	data, err := client.PropFindRaw("a.txt", 0, body)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(data)).To(ContainSubstring("multistatus"))
+	g.Expect(string(data)).To(ContainSubstring(`<D:getcontentlength>5</D:getcontentlength>`))
+
+	_, err = client.PropFindRaw("missing.txt", 0, body)
+	g.Expect(errors.Is(err, os.ErrNotExist)).To(BeTrue())
+}
+
+func TestAddPropfindProperties(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bs, _ := ioutil.ReadAll(r.Body)
+		body = string(bs)
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprint(w, `<?xml version="1.0"?>
+			<d:multistatus xmlns:d="DAV:" xmlns:z="urn:schemas-microsoft-com:">
+				<d:response><d:href>/dir/</d:href><d:propstat><d:prop>
+					<d:resourcetype><d:collection/></d:resourcetype>
+					<z:Win32FileAttributes>00000010</z:Win32FileAttributes>
+				</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>
+				<d:response><d:href>/dir/a.txt</d:href><d:propstat><d:prop>
+					<d:resourcetype/><d:getcontentlength>1</d:getcontentlength>
+					<z:Win32FileAttributes>00000020</z:Win32FileAttributes>
+				</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>
+			</d:multistatus>`)
+	}))
+	defer server.Close()
+
+	attributes := xml.Name{Space: "urn:schemas-microsoft-com:", Local: "Win32FileAttributes"}
+	client := gowebdav.NewClient(server.URL, gowebdav.AddPropfindProperties(attributes))
+
+	files, err := client.ReadDir("dir")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(body).To(ContainSubstring(`xmlns:ns1="urn:schemas-microsoft-com:"`))
+	g.Expect(body).To(ContainSubstring(`<ns1:Win32FileAttributes/>`))
+	g.Expect(body).To(ContainSubstring(`<d:getlastmodified/>`))
+
+	g.Expect(files).To(HaveLen(1))
+	g.Expect(files[0].Size()).To(BeEquivalentTo(1))
+	props := files[0].(interface{ Props() map[xml.Name]string }).Props()
+	g.Expect(props).To(Equal(map[xml.Name]string{attributes: "00000020"}))
+
+	fi, err := client.Stat("dir")
+	g.Expect(err).NotTo(HaveOccurred())
+	props = fi.(interface{ Props() map[xml.Name]string }).Props()
+	g.Expect(props).To(Equal(map[xml.Name]string{attributes: "00000010"}))
+
+	client = gowebdav.NewClient(server.URL, gowebdav.SetPropfindProperties(`<d:propfind xmlns:d="DAV:"><d:allprop/></d:propfind>`))
+	_, err = client.Stat("dir")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(body).To(Equal(`<d:propfind xmlns:d="DAV:"><d:allprop/></d:propfind>`))
+}
+
+func TestSetDAVPrefix(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var bodies []string
+	fs := webdav.NewMemFS()
+	h := &webdav.Handler{FileSystem: fs, LockSystem: webdav.NewMemLS()}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bs, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(bs))
+		r.Body = ioutil.NopCloser(bytes.NewReader(bs))
+		h.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL, gowebdav.SetDAVPrefix("D"))
+	must(t, client.WriteFile("a.txt", []byte("hello"), 0644))
+
+	fi, err := client.Stat("a.txt")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(fi.Size()).To(BeEquivalentTo(5))
+	g.Expect(bodies[len(bodies)-1]).To(HavePrefix(`<D:propfind xmlns:D="DAV:"><D:prop><D:displayname/>`))
+
+	must(t, client.Chtimes("a.txt", time.Now(), time.Now()))
+	g.Expect(bodies[len(bodies)-1]).To(HavePrefix(`<D:propertyupdate xmlns:D="DAV:" xmlns:ns1="urn:schemas-microsoft-com:"><D:set><D:prop><ns1:Win32LastAccessTime>`))
+}
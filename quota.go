@@ -0,0 +1,52 @@
+package gowebdav
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+)
+
+const (
+	// QuotaUnlimited is the quota value reported when there is no storage limit.
+	QuotaUnlimited int64 = -1
+
+	// QuotaUnknown is the quota value reported when the server cannot determine
+	// the quota, or does not support quota properties.
+	QuotaUnknown int64 = -2
+)
+
+var (
+	quotaUsedBytes      = xml.Name{Space: "DAV:", Local: "quota-used-bytes"}
+	quotaAvailableBytes = xml.Name{Space: "DAV:", Local: "quota-available-bytes"}
+)
+
+// Quota returns the number of bytes used and available in the collection at path,
+// using the properties defined by RFC 4331. A value the server does not report is
+// returned as QuotaUnknown; some servers report an available value of -1 (QuotaUnlimited)
+// or -2 (QuotaUnknown) themselves.
+func (c *client) Quota(path string) (used, available int64, err error) {
+	results, err := c.PropFind(path, 0, []xml.Name{quotaUsedBytes, quotaAvailableBytes})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	used, available = QuotaUnknown, QuotaUnknown
+	for _, values := range results {
+		used = parseQuota(values, quotaUsedBytes)
+		available = parseQuota(values, quotaAvailableBytes)
+	}
+	return used, available, nil
+}
+
+func parseQuota(values map[xml.Name]string, name xml.Name) int64 {
+	s, exists := values[name]
+	if !exists {
+		return QuotaUnknown
+	}
+
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return QuotaUnknown
+	}
+	return n
+}
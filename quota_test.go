@@ -0,0 +1,58 @@
+package gowebdav_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/rickb777/gowebdav"
+)
+
+func TestQuota(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	available := "1048576"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.Method).To(Equal("PROPFIND"))
+		g.Expect(r.Header.Get("Depth")).To(Equal("0"))
+
+		body, _ := ioutil.ReadAll(r.Body)
+		g.Expect(string(body)).To(ContainSubstring(`<d:quota-used-bytes/><d:quota-available-bytes/>`))
+
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprintf(w, `<?xml version="1.0"?>
+			<d:multistatus xmlns:d="DAV:">
+				<d:response>
+					<d:href>/files/</d:href>
+					<d:propstat>
+						<d:prop>
+							<d:quota-used-bytes>4096</d:quota-used-bytes>
+							<d:quota-available-bytes>%s</d:quota-available-bytes>
+						</d:prop>
+						<d:status>HTTP/1.1 200 OK</d:status>
+					</d:propstat>
+				</d:response>
+			</d:multistatus>`, available)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	used, avail, err := client.Quota("/files/")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(used).To(Equal(int64(4096)))
+	g.Expect(avail).To(Equal(int64(1048576)))
+
+	available = "-1"
+	_, avail, err = client.Quota("/files/")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(avail).To(Equal(gowebdav.QuotaUnlimited))
+
+	available = "-2"
+	_, avail, err = client.Quota("/files/")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(avail).To(Equal(gowebdav.QuotaUnknown))
+}
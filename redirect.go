@@ -0,0 +1,157 @@
+package gowebdav
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// FollowRedirects controls whether redirect responses are followed, which is the
+// default. When disabled, a redirect gives a *StatusError for the 3xx status.
+//
+// GET and HEAD requests are redirected by http.Client. But it would drop the body
+// of a PROPFIND, PUT etc. when following a 301 or 302 redirect, so the client
+// follows these itself, repeating the same method, headers and body. This only
+// happens for redirects to the same host, without changing from https to http,
+// because the credentials are sent again; see also UpdateRootOnRedirect.
+//
+// This works when the HttpClient is an *http.Client; others are left unchanged.
+func FollowRedirects(follow bool) ClientOpt {
+	return func(c Client) {
+		c.(*client).noFollow = !follow
+	}
+}
+
+// UpdateRootOnRedirect handles redirects from the original root URL to a new base,
+// such as a load balancer redirecting to a canonical host. When a response redirects
+// to a URL that ends with the requested path, the client's root is changed to the
+// new base and the request is repeated there, using the same method. All later
+// requests then go directly to the new root. Other redirects are not followed.
+//
+// Security: the credentials of the authenticator are sent to the new root, which
+// may be a different host. Only use this option when the server is trusted to
+// redirect to a trustworthy location. As a precaution, redirects from https to
+// http are never followed.
+//
+// This works when the HttpClient is an *http.Client; others are left unchanged.
+func UpdateRootOnRedirect() ClientOpt {
+	return func(c Client) {
+		c.(*client).updateRoot = true
+	}
+}
+
+// withoutRedirects returns a copy of hc that does not follow redirects, but instead
+// returns the redirect response itself.
+func withoutRedirects(hc HttpClient) HttpClient {
+	hcc, ok := hc.(*http.Client)
+	if !ok {
+		return hc
+	}
+
+	cp := *hcc
+	cp.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &cp
+}
+
+// maxRedirects limits the redirects followed for one request, like http.Client.
+const maxRedirects = 10
+
+// withoutMethodRedirects returns a copy of hc that follows redirects only for
+// GET and HEAD requests, returning the redirect response itself for the others.
+func withoutMethodRedirects(hc HttpClient) HttpClient {
+	hcc, ok := hc.(*http.Client)
+	if !ok {
+		return hc
+	}
+
+	cp := *hcc
+	check := hcc.CheckRedirect
+	cp.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !followedByHttpClient(via[0].Method) {
+			return http.ErrUseLastResponse
+		}
+		if check != nil {
+			return check(req, via)
+		}
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		return nil
+	}
+	return &cp
+}
+
+func followedByHttpClient(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+// followsItself reports whether the client, rather than the http.Client, follows
+// redirects for method.
+func (c *client) followsItself(method string) bool {
+	_, ok := c.hc.(*http.Client)
+	return ok && !c.noFollow && !c.updateRoot && !followedByHttpClient(method)
+}
+
+// sameHostLocation gets the location of a redirect response, provided that it is
+// on the same host as the request and does not downgrade from https to http.
+func sameHostLocation(res *http.Response) (string, bool) {
+	loc, err := res.Location()
+	if err != nil || res.Request == nil {
+		return "", false
+	}
+
+	from := res.Request.URL
+	if loc.Host != from.Host || (from.Scheme == "https" && loc.Scheme != "https") {
+		return "", false
+	}
+	return loc.String(), true
+}
+
+func isRedirect(statusCode int) bool {
+	switch statusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// rebase changes the root to follow a redirect response for path. It returns
+// false if the redirect location cannot be used as a new root.
+func (c *client) rebase(path string, res *http.Response) bool {
+	loc, err := res.Location()
+	if err != nil {
+		return false
+	}
+
+	if res.Request != nil && res.Request.URL.Scheme == "https" && loc.Scheme != "https" {
+		return false
+	}
+
+	escaped := pathEscape(path)
+	locPath := loc.EscapedPath()
+	if !strings.HasSuffix(locPath, escaped) {
+		return false
+	}
+
+	newRoot := (&url.URL{Scheme: loc.Scheme, User: loc.User, Host: loc.Host}).String() +
+		strings.TrimSuffix(locPath, escaped)
+
+	c.rootMutex.Lock()
+	defer c.rootMutex.Unlock()
+	if newRoot == c.root {
+		return false
+	}
+	c.logf("root changed from %s to %s", c.root, newRoot)
+	c.root = newRoot
+	return true
+}
+
+// getRoot returns the root URL, which may be changed by UpdateRootOnRedirect.
+func (c *client) getRoot() string {
+	c.rootMutex.Lock()
+	defer c.rootMutex.Unlock()
+	return c.root
+}
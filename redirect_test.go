@@ -0,0 +1,125 @@
+package gowebdav_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/rickb777/gowebdav"
+	"golang.org/x/net/webdav"
+)
+
+func TestUpdateRootOnRedirect(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	canonical := httptest.NewServer(&webdav.Handler{
+		Prefix:     "/dav",
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer canonical.Close()
+
+	redirects := 0
+	balancer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		redirects++
+		http.Redirect(w, r, canonical.URL+"/dav"+strings.TrimPrefix(r.URL.Path, "/old"), http.StatusMovedPermanently)
+	}))
+	defer balancer.Close()
+
+	client := gowebdav.NewClient(balancer.URL+"/old", gowebdav.UpdateRootOnRedirect())
+
+	must(t, client.WriteFile("a.txt", []byte("hello"), 0644))
+	g.Expect(redirects).To(Equal(1))
+	g.Expect(client.Name()).To(Equal("webdav:" + canonical.URL + "/dav"))
+
+	fi, err := client.Stat("a.txt")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(fi.Size()).To(Equal(int64(5)))
+	g.Expect(redirects).To(Equal(1))
+}
+
+func TestFollowRedirects_disabled(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/elsewhere", http.StatusFound)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL, gowebdav.FollowRedirects(false))
+
+	_, err := client.ReadStream("a.txt")
+	var se *gowebdav.StatusError
+	g.Expect(err).To(BeAssignableToTypeOf(se))
+	g.Expect(err.(*gowebdav.StatusError).StatusCode).To(Equal(http.StatusFound))
+}
+
+func TestRedirect_webdavMethods(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	handler := &webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	}
+
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/old/") {
+			methods = append(methods, r.Method)
+			http.Redirect(w, r, "/new/"+strings.TrimPrefix(r.URL.Path, "/old/"), http.StatusMovedPermanently)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+	must(t, client.Mkdir("new", 0755))
+	must(t, client.WriteFile("new/a.txt", []byte("hello"), 0644))
+
+	fi, err := client.Stat("old/a.txt")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(fi.Size()).To(Equal(int64(5)))
+
+	must(t, client.Mkdir("old/dir", 0755))
+	must(t, client.Copy("old/a.txt", "new/b.txt"))
+	files, err := client.ReadDir("old")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(files).To(HaveLen(3))
+
+	g.Expect(methods).To(Equal([]string{"PROPFIND", "MKCOL", "COPY", "PROPFIND"}))
+}
+
+func TestRedirect_loopsAndOtherHosts(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected %s request to other host", r.Method)
+	}))
+	defer other.Close()
+
+	count := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/elsewhere/" {
+			http.Redirect(w, r, other.URL+"/x/", http.StatusTemporaryRedirect)
+			return
+		}
+		count++
+		http.Redirect(w, r, r.URL.Path, http.StatusFound)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	_, err := client.Stat("loop")
+	g.Expect(err).To(MatchError(ContainSubstring("stopped after 10 redirects")))
+	g.Expect(count).To(Equal(11))
+
+	err = client.Mkdir("elsewhere", 0755)
+	var se *gowebdav.StatusError
+	g.Expect(errors.As(err, &se)).To(BeTrue())
+	g.Expect(se.StatusCode).To(Equal(http.StatusTemporaryRedirect))
+}
@@ -2,19 +2,47 @@ package gowebdav
 
 import (
 	"bytes"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	authpkg "github.com/rickb777/gowebdav/auth"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"net/http/cookiejar"
+	"net/url"
 	pathpkg "path"
+	"runtime/debug"
+	"strconv"
 	"strings"
+	"time"
 )
 
-func (c *client) request(method, path string, body io.Reader, intercept func(*http.Request)) (req *http.Response, err error) {
+func (c *client) request(op, method, path string, body io.Reader, intercept func(*http.Request)) (*http.Response, error) {
+	return c.requestTo(op, method, path, c.resourceURL(path), 0, body, intercept)
+}
+
+// requestTo sends a request for path to the URL u, which differs from the URL of
+// path when a redirect is being followed.
+func (c *client) requestTo(op, method, path, u string, redirects int, body io.Reader, intercept func(*http.Request)) (req *http.Response, err error) {
+	// Make sure we read 'c.auth' only once because it may be substituted below,
+	// which is unsafe to do when multiple goroutines are running at the same time.
+	c.authMutex.Lock()
+	auth := c.auth
+	c.authMutex.Unlock()
+
+	// When the authentication method is still to be chosen from the server's
+	// challenge, a 401 response means the request is sent again.
+	mayResend := auth.Type() == "NoAuth"
+
 	// Tee the body, because if authorization fails we will need to read from it again.
+	// This isn't needed if no re-send is expected, so large streams are not buffered,
+	// nor if the body can be re-read by seeking back to where it started.
 	var r *http.Request
 	var ba *bytes.Buffer
 	var bb io.Reader
+	var seeker io.Seeker
+	var offset int64
 	if body != nil {
 		switch v := body.(type) {
 		case *bytes.Buffer:
@@ -22,13 +50,35 @@ func (c *client) request(method, path string, body io.Reader, intercept func(*ht
 			ba = bytes.NewBuffer(v.Bytes())
 			bb = bytes.NewReader(v.Bytes())
 		default:
-			// an extra buffer and tee copying of the bytes
-			ba = &bytes.Buffer{}
-			bb = io.TeeReader(body, ba)
+			if !mayResend {
+				bb = body
+			} else if seeker, offset = seekable(body); seeker != nil {
+				// the transport closes the body, so it must not see the Closer
+				bb = ioutil.NopCloser(body)
+			} else {
+				// an extra buffer and tee copying of the bytes
+				ba = &bytes.Buffer{}
+				bb = io.TeeReader(body, ba)
+			}
 		}
 	}
 
-	u := c.root + pathEscape(path)
+	// resend gets the body for sending the request again.
+	resend := func() (io.Reader, error) {
+		switch {
+		case body == nil:
+			return nil, nil
+		case ba != nil:
+			return ba, nil
+		case seeker != nil:
+			if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+				return nil, err
+			}
+			return body, nil
+		}
+		return nil, errors.New("the request body cannot be sent again")
+	}
+
 	if body == nil {
 		r, err = http.NewRequest(method, u, nil)
 	} else {
@@ -45,150 +95,456 @@ func (c *client) request(method, path string, body io.Reader, intercept func(*ht
 		}
 	}
 
-	// Make sure we read 'c.auth' only once because it may be substituted below,
-	// which is unsafe to do when multiple goroutines are running at the same time.
-	c.authMutex.Lock()
-	auth := c.auth
-	c.authMutex.Unlock()
+	if c.userAgent != "" {
+		r.Header.Set("User-Agent", c.userAgent)
+	} else if r.Header.Get("User-Agent") == "" {
+		r.Header.Set("User-Agent", defaultUserAgent)
+	}
 
-	auth.Authorize(r)
+	if c.preferMinimal && writeMethods[method] {
+		r.Header.Set("Prefer", "return=minimal")
+	}
+
+	if err = auth.Authorize(r); err != nil {
+		return nil, err
+	}
 
 	if intercept != nil {
 		intercept(r)
 	}
 
-	res, err := c.hc.Do(r)
+	c.submitLockTokens(r, path)
+
+	start := time.Now()
+	res, err := c.do(r, body)
+	c.observe(op, path, res, time.Since(start), err)
 	if err != nil {
 		return nil, err
 	}
 
-	if res.StatusCode == http.StatusUnauthorized && auth.Type() == "noAuth" {
-		wwwAuthenticateHeader := res.Header.Get("Www-Authenticate")
-		wwwAuthenticateHeaderLC := strings.ToLower(wwwAuthenticateHeader)
+	if ba != nil && r.Header.Get("Expect") == "100-continue" && (res.StatusCode == http.StatusUnauthorized || isRedirect(res.StatusCode)) {
+		// The body was probably not sent, so read the rest of it into ba in case the
+		// request is sent again. Without Expect, the transport may still be reading it.
+		_, _ = io.Copy(ioutil.Discard, bb)
+	}
+
+	if c.updateRoot && isRedirect(res.StatusCode) && c.rebase(path, res) {
+		_ = res.Body.Close()
+
+		rb, err := resend()
+		if err != nil {
+			return nil, newPathErrorErr(method, path, err)
+		}
+		return c.request(op, method, path, rb, intercept)
+	}
+
+	if c.followsItself(method) && isRedirect(res.StatusCode) && (body == nil || ba != nil || seeker != nil) {
+		if loc, ok := sameHostLocation(res); ok {
+			_ = res.Body.Close()
+			if redirects >= maxRedirects {
+				return nil, newPathErrorErr(method, path, fmt.Errorf("stopped after %d redirects", maxRedirects))
+			}
+
+			c.logf("following %d redirect from %s to %s", res.StatusCode, u, loc)
+			rb, err := resend()
+			if err != nil {
+				return nil, newPathErrorErr(method, path, err)
+			}
+			return c.requestTo(op, method, path, loc, redirects+1, rb, intercept)
+		}
+	}
+
+	if res.StatusCode == http.StatusUnauthorized && mayResend {
+		// servers may send one header per scheme; Digest is preferred over Basic
+		challenges := res.Header.Values("Www-Authenticate")
 
-		if strings.Contains(wwwAuthenticateHeaderLC, "digest") {
+		if authpkg.Offers(challenges, "Digest") {
 			c.authMutex.Lock()
-			c.auth = authpkg.Digest(auth.User(), auth.Password()).DigestParts(wwwAuthenticateHeader)
+			c.auth = authpkg.Digest(auth.User(), auth.Password()).DigestParts(strings.Join(challenges, ", "))
 			c.authMutex.Unlock()
-		} else if strings.Contains(wwwAuthenticateHeaderLC, "basic") {
+		} else if authpkg.Offers(challenges, "Basic") {
 			c.authMutex.Lock()
 			c.auth = authpkg.Basic(auth.User(), auth.Password())
 			c.authMutex.Unlock()
 		} else {
-			return res, newPathError("Authorize", c.root, res.StatusCode)
+			_ = res.Body.Close()
+			return nil, newPathError("Authorize", c.getRoot(), res.StatusCode)
 		}
 
 		_ = res.Body.Close()
 
-		if body == nil {
-			return c.request(method, path, nil, intercept)
-		} else {
-			return c.request(method, path, ba, intercept)
+		rb, err := resend()
+		if err != nil {
+			return nil, newPathErrorErr(method, path, err)
 		}
+		return c.requestTo(op, method, path, u, redirects, rb, intercept)
 
 	} else if res.StatusCode == http.StatusUnauthorized {
-		return res, newPathError("Authorize", c.root, res.StatusCode)
+		_ = res.Body.Close()
+		return nil, newPathError("Authorize", c.getRoot(), res.StatusCode)
 	}
 
 	return res, err
 }
 
-func (c *client) mkcol(path string) int {
-	res, err := c.request(MethodMkcol, withLeadingSlash(path), nil, nil)
+// defaultUserAgent is sent unless another User-Agent is set.
+var defaultUserAgent = userAgentVersion("gowebdav", "github.com/rickb777/gowebdav")
+
+// userAgentVersion appends the version of the module to name, if it is known.
+func userAgentVersion(name, module string) string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return name
+	}
+
+	if info.Main.Path == module && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return name + "/" + info.Main.Version
+	}
+
+	for _, dep := range info.Deps {
+		if dep.Path == module && dep.Version != "" {
+			return name + "/" + dep.Version
+		}
+	}
+	return name
+}
+
+// seekable gets the current offset of body if it can seek, such as an *os.File for
+// a regular file. Otherwise, the seeker is nil.
+func seekable(body io.Reader) (io.Seeker, int64) {
+	if s, ok := body.(io.Seeker); ok {
+		if offset, err := s.Seek(0, io.SeekCurrent); err == nil {
+			return s, offset
+		}
+	}
+	return nil, 0
+}
+
+// writeMethods are the methods that SetPreferMinimal applies to.
+var writeMethods = map[string]bool{
+	http.MethodPut:    true,
+	http.MethodDelete: true,
+	MethodCopy:        true,
+	MethodMove:        true,
+	MethodMkcol:       true,
+	MethodProppatch:   true,
+}
+
+// withOpts returns an interceptor that applies the per-operation options
+// after any other interception.
+func withOpts(intercept func(*http.Request), opts []OpOpt) func(*http.Request) {
+	if len(opts) == 0 {
+		return intercept
+	}
+	return func(rq *http.Request) {
+		if intercept != nil {
+			intercept(rq)
+		}
+		for _, opt := range opts {
+			opt(rq)
+		}
+	}
+}
+
+// mkcol sends a MKCOL request and returns the response status. The error is
+// only for failures to send the request.
+func (c *client) mkcol(op, path string, opts ...OpOpt) (int, error) {
+	res, err := c.request(op, MethodMkcol, withLeadingSlash(path), nil, withOpts(nil, opts))
 	if err != nil {
-		return http.StatusBadRequest
+		return 0, newPathErrorErr(op, path, err)
 	}
 	defer res.Body.Close()
 
-	// TODO explain why???
-	if res.StatusCode == http.StatusMethodNotAllowed {
-		return http.StatusCreated
-	}
+	return res.StatusCode, nil
+}
 
-	return res.StatusCode
+// mkcolExists reports whether a failed MKCOL was due to path already existing
+// as a collection. RFC 4918 requires 405 Method Not Allowed for this, but some
+// servers respond 409 Conflict, which otherwise means the parent is missing.
+func (c *client) mkcolExists(path string, status int) bool {
+	switch status {
+	case http.StatusMethodNotAllowed:
+		return true
+	case http.StatusConflict:
+		fi, err := c.Stat(path)
+		return err == nil && fi.IsDir()
+	}
+	return false
 }
 
-func (c *client) options(path string) (*http.Response, error) {
-	return c.request(http.MethodOptions, withLeadingSlash(path), nil, func(rq *http.Request) {
+func (c *client) options(op, path string) (*http.Response, error) {
+	return c.request(op, http.MethodOptions, withLeadingSlash(path), nil, func(rq *http.Request) {
 		rq.Header.Add("Depth", "0")
 	})
 }
 
-func (c *client) propfind(path string, self bool, body string, resp interface{}, parse func(resp interface{}) error) error {
+func (c *client) propfind(op, path string, depth int, body string, resp interface{}, parse func(resp interface{}) error, opts ...OpOpt) error {
+	res, err := c.propfindResponse(op, path, depth, body, opts...)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	return parseXML(res.Body, res.Header.Get("Content-Type"), resp, parse)
+}
+
+// propfindResponse sends a PROPFIND request and checks that the response is a
+// multistatus, which it decompresses if need be. The caller must close the body.
+func (c *client) propfindResponse(op, path string, depth int, body string, opts ...OpOpt) (*http.Response, error) {
 	path = withLeadingSlash(path)
-	res, err := c.request(MethodPropfind, path, strings.NewReader(body), func(req *http.Request) {
-		if self {
-			req.Header.Add("Depth", "0")
+	res, err := c.request(op, MethodPropfind, path, bytes.NewBufferString(body), withOpts(func(req *http.Request) {
+		if depth == DepthInfinity {
+			req.Header.Add("Depth", "infinity")
 		} else {
-			req.Header.Add("Depth", "1")
+			req.Header.Add("Depth", strconv.Itoa(depth))
 		}
 		req.Header.Add("Content-Type", "application/xml;charset=UTF-8")
 		req.Header.Add("Accept", "application/xml,text/xml")
 		req.Header.Add("Accept-Charset", "utf-8")
-		// TODO add support for 'gzip,deflate;q=0.8,q=0.7'
-		req.Header.Add("Accept-Encoding", "")
+		c.acceptGzip(req)
+	}, opts))
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusMultiStatus {
+		err = responseError(MethodPropfind, path, res)
+		_ = res.Body.Close()
+		return nil, err
+	}
+
+	if err = decompress(res); err != nil {
+		_ = res.Body.Close()
+		return nil, newPathErrorErr(MethodPropfind, path, err)
+	}
+
+	return res, nil
+}
+
+// proppatch sends a propertyupdate body. The properties are changed all together
+// or not at all, so any failure is reported using the status code of the first
+// property that failed.
+func (c *client) proppatch(op, path string, body string) error {
+	path = withLeadingSlash(path)
+	c.InvalidateCache(path)
+	res, err := c.request(op, MethodProppatch, path, bytes.NewBufferString(body), func(req *http.Request) {
+		req.Header.Add("Content-Type", "application/xml;charset=UTF-8")
 	})
 	if err != nil {
-		return err
+		return newPathErrorErr(op, path, err)
 	}
 	defer res.Body.Close()
 
-	if res.StatusCode != http.StatusMultiStatus {
-		return fmt.Errorf("%s - %s %s", res.Status, MethodPropfind, path)
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+
+	case http.StatusMultiStatus:
+		ok, failures, err := c.evaluateMultiStatus(res.Body)
+		if err != nil {
+			return newPathErrorErr(op, path, err)
+		}
+		if !ok {
+			// the properties are set all together or not at all
+			return newPathError(op, path, failures[0].StatusCode)
+		}
+		return nil
 	}
 
-	return parseXML(res.Body, resp, parse)
+	return responseError(op, path, res)
 }
 
-func (c *client) copymove(method string, oldpath string, newpath string, overwrite bool) error {
+// copymove copies or moves oldpath. The depth is "infinity", which MOVE requires,
+// or "0", which copies a collection without its members.
+func (c *client) copymove(op, method string, oldpath string, newpath string, depth string, overwrite bool, opts ...OpOpt) error {
 	oldpath = withLeadingSlash(oldpath)
 	newpath = withLeadingSlash(newpath)
+	c.InvalidateCache(oldpath)
+	c.InvalidateCache(newpath)
+
+	status, err := c.copymoveTo(op, method, oldpath, c.resourceURL(newpath), depth, overwrite, opts...)
+
+	switch status {
+	case http.StatusConflict:
+		if c.noParents {
+			return err
+		}
+
+		err := c.createParentCollection(newpath)
+		if err != nil {
+			return err
+		}
+
+		return c.copymove(op, method, oldpath, newpath, depth, overwrite, opts...)
+
+	case http.StatusPreconditionFailed:
+		// RFC 4918 says that with Overwrite: T, an existing destination is deleted
+		// first, but some servers refuse to overwrite a collection. So delete it here
+		// and try again, unless the 412 could be due to the caller's preconditions.
+		if overwrite && len(opts) == 0 {
+			if fi, err := c.Stat(newpath); err == nil && fi.IsDir() {
+				if err = c.RemoveAll(newpath); err != nil {
+					return err
+				}
+				return c.copymove(op, method, oldpath, newpath, depth, overwrite)
+			}
+		}
+	}
 
-	res, err := c.request(method, oldpath, nil, func(rq *http.Request) {
-		rq.Header.Add("Destination", c.root+newpath)
+	return err
+}
+
+// copymoveTo copies or moves oldpath to the destination URL. As well as any
+// error, it returns the response status, so that the caller can recover from
+// some failures.
+func (c *client) copymoveTo(op, method, oldpath, destination, depth string, overwrite bool, opts ...OpOpt) (int, error) {
+	res, err := c.request(op, method, oldpath, nil, withOpts(func(rq *http.Request) {
+		rq.Header.Add("Destination", destination)
+		rq.Header.Add("Depth", depth)
 		if overwrite {
 			rq.Header.Add("Overwrite", "T")
 		} else {
 			rq.Header.Add("Overwrite", "F")
 		}
-	})
+	}, opts))
 	if err != nil {
-		return newPathErrorErr(method, oldpath, err)
+		return 0, newPathErrorErr(method, oldpath, err)
 	}
 
 	defer res.Body.Close()
 
 	switch res.StatusCode {
-	case http.StatusCreated, http.StatusNoContent:
-		return nil
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return res.StatusCode, nil
 
 	case http.StatusMultiStatus:
-		// TODO handle multistat errors, worst case ...
-		log(fmt.Sprintf(" TODO handle %s - %s multistatus result %s", method, oldpath, readString(res.Body)))
-
-	case http.StatusConflict:
-		err := c.createParentCollection(newpath)
+		// some members of the collection may not have been copied or moved
+		ok, failures, err := c.evaluateMultiStatus(res.Body)
 		if err != nil {
-			return err
+			return res.StatusCode, newPathErrorErr(method, oldpath, err)
+		}
+		if !ok {
+			return res.StatusCode, &MultiStatusError{Op: method, Path: oldpath, Failures: failures}
+		}
+		return res.StatusCode, nil
+	}
+
+	return res.StatusCode, responseError(method, oldpath, res)
+}
+
+// copymoveURL copies or moves oldpath to destURL, which must be an absolute
+// http or https URL. It is sent verbatim as the Destination.
+func (c *client) copymoveURL(op, method, oldpath, destURL string, overwrite bool) error {
+	oldpath = withLeadingSlash(oldpath)
+	u, err := url.Parse(destURL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return newPathErrorErr(op, oldpath, fmt.Errorf("invalid destination URL %q", destURL))
+	}
+
+	c.InvalidateCache(oldpath)
+	_, err = c.copymoveTo(op, method, oldpath, destURL, "infinity", overwrite)
+	return err
+}
+
+// statusResponse is a multistatus response element that reports the status of
+// whole resources or, for PROPPATCH, of their properties.
+type statusResponse struct {
+	Hrefs     []string `xml:"DAV: href"`
+	Status    string   `xml:"DAV: status"`
+	Propstats []struct {
+		Status      string `xml:"DAV: status"`
+		Description string `xml:"DAV: responsedescription"`
+	} `xml:"DAV: propstat"`
+	Description string `xml:"DAV: responsedescription"`
+}
+
+type statusMultistatus struct {
+	XMLName   xml.Name         `xml:"DAV: multistatus"`
+	Responses []statusResponse `xml:"DAV: response"`
+}
+
+// evaluateMultiStatus parses the multistatus body of a response to COPY, MOVE,
+// DELETE or PROPPATCH. It is ok if every status in it, whether of a resource or
+// of a set of properties, is 2xx; otherwise the failures list the resources and
+// their statuses. A body that cannot be read completely, or that is not a
+// multistatus document, gives an error.
+func (c *client) evaluateMultiStatus(body io.Reader) (ok bool, failures []ResourceError, err error) {
+	s, err := readString(body)
+	if err != nil {
+		return false, nil, err
+	}
+	c.logf("multistatus response: %s", s)
+
+	var ms statusMultistatus
+	decoder, _ := xmlDecoder(strings.NewReader(s), "")
+	if err = decoder.Decode(&ms); err != nil {
+		return false, nil, fmt.Errorf("invalid multistatus response: %w", err)
+	}
+
+	fail := func(hrefs []string, status, description string) {
+		code := parseStatusCode(status)
+		if code != 0 && (code < 200 || code > 299) {
+			for _, href := range hrefs {
+				failures = append(failures, ResourceError{
+					Path:        c.hrefPath(href),
+					StatusCode:  code,
+					Description: strings.TrimSpace(description),
+				})
+			}
 		}
+	}
 
-		return c.copymove(method, oldpath, newpath, overwrite)
+	for _, r := range ms.Responses {
+		fail(r.Hrefs, r.Status, r.Description)
+		for _, ps := range r.Propstats {
+			description := ps.Description
+			if description == "" {
+				description = r.Description
+			}
+			fail(r.Hrefs, ps.Status, description)
+		}
 	}
+	return len(failures) == 0, failures, nil
+}
 
-	return newPathError(method, oldpath, res.StatusCode)
+// resourceURL builds the URL of path, which is relative to the root. The root is
+// already escaped; the path is not.
+func (c *client) resourceURL(path string) string {
+	root := c.getRoot()
+	u, err := url.Parse(root)
+	if err != nil {
+		return root + pathEscape(path)
+	}
+	u.RawPath = u.EscapedPath() + pathEscape(path)
+	u.Path += path
+	return u.String()
 }
 
-func (c *client) put(path string, stream io.Reader) int {
-	res, err := c.request(http.MethodPut, withLeadingSlash(path), stream, nil)
+// put uploads stream to path. As well as the response status, it returns the
+// error for that status, which is nil for success, or the error that prevented
+// the request being sent, with a zero status.
+func (c *client) put(op, path string, stream io.Reader, opts ...OpOpt) (int, error) {
+	c.InvalidateCache(path)
+	res, err := c.request(op, http.MethodPut, withLeadingSlash(path), stream, withOpts(c.expect, opts))
 	if err != nil {
-		return http.StatusBadRequest
+		return 0, newPathErrorErr(op, path, err)
 	}
-	_ = res.Body.Close()
+	defer res.Body.Close()
 
-	return res.StatusCode
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return res.StatusCode, nil
+	}
+	return res.StatusCode, responseError(op, path, res)
 }
 
+// createParentCollection makes the collections above itemPath, unless this has
+// been disabled by SetAutoCreateParents.
 func (c *client) createParentCollection(itemPath string) (err error) {
+	if c.noParents {
+		return nil
+	}
+
 	parentPath := pathpkg.Dir(withLeadingSlash(itemPath))
 	if parentPath == "." || parentPath == "/" {
 		return nil
@@ -196,3 +552,46 @@ func (c *client) createParentCollection(itemPath string) (err error) {
 
 	return c.MkdirAll(parentPath, 0755)
 }
+
+// withCookieJar returns a copy of hc with a new cookie jar, unless hc already has one.
+func withCookieJar(hc HttpClient) HttpClient {
+	hcc, ok := hc.(*http.Client)
+	if !ok || hcc.Jar != nil {
+		return hc
+	}
+
+	jar, _ := cookiejar.New(nil) // never returns an error
+	cp := *hcc
+	cp.Jar = jar
+	return &cp
+}
+
+// withTransport returns a copy of hc that uses a clone of its transport, adjusted by
+// each of the options. The original client and transport are not altered. This is
+// only possible when hc is an *http.Client with an *http.Transport (or the default
+// transport); otherwise hc is returned unchanged.
+func withTransport(hc HttpClient, opts []func(*http.Transport)) HttpClient {
+	hcc, ok := hc.(*http.Client)
+	if !ok {
+		return hc
+	}
+
+	rt := hcc.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+
+	t, ok := rt.(*http.Transport)
+	if !ok {
+		return hc
+	}
+
+	t = t.Clone()
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	cp := *hcc
+	cp.Transport = t
+	return &cp
+}
@@ -0,0 +1,157 @@
+package gowebdav
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// retryPolicy controls the retrying of requests that fail transiently.
+type retryPolicy struct {
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+}
+
+// defaultMaxRetryDelay is the longest delay before a retry, unless changed by
+// SetMaxRetryDelay.
+const defaultMaxRetryDelay = time.Minute
+
+// SetRetryPolicy retries requests that fail with 429, 502, 503 or 504, or with a
+// connection reset, up to maxRetries times. The delay doubles after each attempt,
+// starting from baseDelay, unless the server sends a Retry-After header. No delay
+// is longer than one minute, or the limit set by SetMaxRetryDelay. If the request's
+// context is done during a delay, the request is abandoned with the context's error.
+//
+// Only idempotent methods (GET, HEAD, OPTIONS, PROPFIND, MKCOL) without a body are
+// retried, plus any request whose body was supplied as a *bytes.Buffer, because that
+// can be sent again. Streamed uploads are never retried.
+func SetRetryPolicy(maxRetries int, baseDelay time.Duration) ClientOpt {
+	return func(c Client) {
+		cl := c.(*client)
+		cl.retry.maxRetries = maxRetries
+		cl.retry.baseDelay = baseDelay
+	}
+}
+
+// SetMaxRetryDelay limits the delay before each retry made under SetRetryPolicy,
+// including delays requested by the server with Retry-After.
+func SetMaxRetryDelay(max time.Duration) ClientOpt {
+	return func(c Client) {
+		c.(*client).retry.maxDelay = max
+	}
+}
+
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	MethodPropfind:     true,
+	MethodMkcol:        true,
+}
+
+// do sends the request, retrying it according to the retry policy.
+func (c *client) do(r *http.Request, body io.Reader) (*http.Response, error) {
+	res, err := c.send(r)
+
+	_, isBuffer := body.(*bytes.Buffer)
+	canRetry := isBuffer || (body == nil && idempotentMethods[r.Method])
+	if !canRetry {
+		return res, err
+	}
+
+	for attempt := 0; attempt < c.retry.maxRetries && isTransient(res, err); attempt++ {
+		delay := c.retry.limit(retryAfter(res, c.retry.baseDelay<<uint(attempt)))
+		c.logf("retrying %s %s in %v (attempt %d of %d)", r.Method, r.URL, delay, attempt+1, c.retry.maxRetries)
+		if res != nil {
+			_, _ = io.Copy(ioutil.Discard, res.Body)
+			_ = res.Body.Close()
+		}
+
+		if err = sleep(r.Context(), delay); err != nil {
+			return nil, err
+		}
+
+		r = r.Clone(r.Context())
+		if r.GetBody != nil {
+			if r.Body, err = r.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+
+		res, err = c.send(r)
+	}
+
+	return res, err
+}
+
+// limit caps a delay at the maximum.
+func (p retryPolicy) limit(delay time.Duration) time.Duration {
+	max := p.maxDelay
+	if max <= 0 {
+		max = defaultMaxRetryDelay
+	}
+	if delay > max || delay < 0 {
+		return max
+	}
+	return delay
+}
+
+// sleep waits for the delay, or until ctx is done, in which case it returns the
+// context's error.
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// isTransient reports whether a response or error is worth retrying.
+func isTransient(res *http.Response, err error) bool {
+	if err != nil {
+		return errors.Is(err, syscall.ECONNRESET)
+	}
+
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter gets the delay from a Retry-After header, which is either a number
+// of seconds or an HTTP date. Otherwise, it returns the fallback delay.
+func retryAfter(res *http.Response, fallback time.Duration) time.Duration {
+	if res == nil {
+		return fallback
+	}
+
+	ra := res.Header.Get("Retry-After")
+	if ra == "" {
+		return fallback
+	}
+
+	if secs, err := strconv.Atoi(ra); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second
+	}
+
+	if t, err := http.ParseTime(ra); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+		return 0
+	}
+
+	return fallback
+}
@@ -0,0 +1,93 @@
+package gowebdav_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/rickb777/gowebdav"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var requests int
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		bs, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(bs))
+		if requests%3 != 0 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Method == http.MethodPut {
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer server.Close()
+
+	var messages []string
+	client := gowebdav.NewClient(server.URL,
+		gowebdav.SetRetryPolicy(3, time.Millisecond),
+		gowebdav.SetLogger(func(msg string) { messages = append(messages, msg) }))
+
+	t.Logf("GET is retried\n")
+	_, err := client.ReadFile("a.txt")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(requests).To(Equal(3))
+	g.Expect(messages).To(HaveLen(2))
+	g.Expect(messages[0]).To(Equal("retrying GET " + server.URL + "/a.txt in 0s (attempt 1 of 3)"))
+
+	t.Logf("PUT with a buffered body is retried\n")
+	requests, bodies = 0, nil
+	must(t, client.WriteFile("a.txt", []byte("hello"), 0644))
+	g.Expect(requests).To(Equal(3))
+	g.Expect(bodies).To(Equal([]string{"hello", "hello", "hello"}))
+
+	t.Logf("PUT with a streamed body is not retried\n")
+	requests, bodies = 0, nil
+	err = client.WriteStream("a.txt", strings.NewReader("hello"), 0644)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(requests).To(Equal(1))
+}
+
+func TestRetryPolicy_delays(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var messages []string
+	client := gowebdav.NewClient(server.URL,
+		gowebdav.SetRetryPolicy(1, time.Millisecond),
+		gowebdav.SetMaxRetryDelay(10*time.Millisecond),
+		gowebdav.SetLogger(func(msg string) { messages = append(messages, msg) }))
+
+	t.Logf("Retry-After is limited by the maximum delay\n")
+	_, err := client.ReadFile("a.txt")
+	var se *gowebdav.StatusError
+	g.Expect(errors.As(err, &se)).To(BeTrue())
+	g.Expect(se.StatusCode).To(Equal(http.StatusServiceUnavailable))
+	g.Expect(messages).To(Equal([]string{"retrying GET " + server.URL + "/a.txt in 10ms (attempt 1 of 1)"}))
+
+	t.Logf("the delay ends when the context is done\n")
+	client = gowebdav.NewClient(server.URL, gowebdav.SetRetryPolicy(1, time.Millisecond), gowebdav.SetMaxRetryDelay(time.Hour))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = client.ReadStream("a.txt", gowebdav.WithContext(ctx))
+	g.Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+	g.Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+}
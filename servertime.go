@@ -0,0 +1,51 @@
+package gowebdav
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ServerTime gets the server's clock time from the Date header of an OPTIONS
+// response for the root. The Date header only has a resolution of one second.
+// Any response status is accepted, provided the header is present.
+func (c *client) ServerTime() (time.Time, error) {
+	t, _, err := c.serverTime()
+	return t, err
+}
+
+// ClockSkew estimates how far the server's clock is ahead of the local clock; it
+// is negative if the server's clock is behind. Timing differences of this order
+// can upset Digest authentication and comparisons of modification times, such as
+// in incremental syncs. The server time is compared with the local time halfway
+// through the request, but as the Date header is in whole seconds, the result is
+// only accurate to about one second.
+func (c *client) ClockSkew() (time.Duration, error) {
+	t, local, err := c.serverTime()
+	if err != nil {
+		return 0, err
+	}
+	return t.Sub(local), nil
+}
+
+// serverTime gets the server time and the local time halfway through the request.
+func (c *client) serverTime() (time.Time, time.Time, error) {
+	before := time.Now()
+	rs, err := c.options("ServerTime", "/")
+	if err != nil {
+		return time.Time{}, time.Time{}, newPathErrorErr("ServerTime", "/", err)
+	}
+	_ = rs.Body.Close()
+	local := before.Add(time.Since(before) / 2)
+
+	date := rs.Header.Get("Date")
+	if date == "" {
+		return time.Time{}, time.Time{}, newPathErrorErr("ServerTime", "/", errors.New("the response has no Date header"))
+	}
+
+	t, err := http.ParseTime(date)
+	if err != nil {
+		return time.Time{}, time.Time{}, newPathErrorErr("ServerTime", "/", err)
+	}
+	return t, local, nil
+}
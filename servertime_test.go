@@ -0,0 +1,42 @@
+package gowebdav_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/rickb777/gowebdav"
+)
+
+func TestServerTime_ClockSkew(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	offset := time.Hour
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.Method).To(Equal(http.MethodOptions))
+		if offset == 0 {
+			w.Header()["Date"] = nil // suppresses the automatic header
+		} else {
+			w.Header().Set("Date", time.Now().Add(offset).UTC().Format(http.TimeFormat))
+		}
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	st, err := client.ServerTime()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(st).To(BeTemporally("~", time.Now().Add(time.Hour), 2*time.Second))
+
+	offset = -time.Minute
+	skew, err := client.ClockSkew()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(skew).To(BeNumerically("~", -time.Minute, 2*time.Second))
+
+	offset = 0
+	_, err = client.ClockSkew()
+	g.Expect(err).To(MatchError(ContainSubstring("no Date header")))
+}
@@ -0,0 +1,79 @@
+package gowebdav
+
+import (
+	"net/http"
+	"os"
+	pathpkg "path"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// EnableStatCache keeps the result of each Stat for up to ttl. When a path is
+// looked up again, the PROPFIND is made conditional on the cached ETag using
+// If-None-Match, and the cached result is returned if the server responds 304
+// Not Modified. Entries older than ttl are never used, so a ttl that is zero or
+// negative disables the cache.
+//
+// Changes made through this client remove the affected paths from the cache;
+// use InvalidateCache for changes made by other means.
+func EnableStatCache(ttl time.Duration) ClientOpt {
+	return func(c Client) {
+		if ttl <= 0 {
+			c.(*client).statCache = nil
+			return
+		}
+		c.(*client).statCache = cache.New(ttl, 2*ttl)
+	}
+}
+
+// InvalidateCache removes path from the Stat cache, if there is one.
+func (c *client) InvalidateCache(path string) {
+	if c.statCache != nil {
+		c.statCache.Delete(cacheKey(path))
+	}
+}
+
+func cacheKey(path string) string {
+	return pathpkg.Clean("/" + path)
+}
+
+// cachedStat returns the cached file information for path, if any.
+func (c *client) cachedStat(path string) *fileinfo {
+	if c.statCache == nil {
+		return nil
+	}
+	if v, found := c.statCache.Get(cacheKey(path)); found {
+		return v.(*fileinfo)
+	}
+	return nil
+}
+
+// statConditionally issues Stat's PROPFIND, conditional on the cached ETag if
+// there is one. On 304 Not Modified, it returns the cached value.
+func (c *client) statConditionally(path string) (os.FileInfo, error) {
+	cached := c.cachedStat(path)
+	if cached == nil || cached.etag == "" {
+		fi, err := c.stat(path)
+		c.storeStat(path, fi, err)
+		return fi, err
+	}
+
+	fi, err := c.stat(path, WithIfNoneMatch(cached.etag))
+	if hasStatus(err, http.StatusNotModified) {
+		return cached, nil
+	}
+	c.storeStat(path, fi, err)
+	return fi, err
+}
+
+func (c *client) storeStat(path string, fi *fileinfo, err error) {
+	if c.statCache == nil {
+		return
+	}
+	if err != nil || fi == nil {
+		c.statCache.Delete(cacheKey(path))
+		return
+	}
+	c.statCache.SetDefault(cacheKey(path), fi)
+}
@@ -0,0 +1,80 @@
+package gowebdav_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/rickb777/gowebdav"
+)
+
+func TestEnableStatCache(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	etag := `"v1"`
+	size := 100
+	var requests, notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprintf(w, `<?xml version="1.0"?>
+			<d:multistatus xmlns:d="DAV:">
+				<d:response>
+					<d:href>/a.txt</d:href>
+					<d:propstat>
+						<d:prop>
+							<d:getcontentlength>%d</d:getcontentlength>
+							<d:getetag>%s</d:getetag>
+						</d:prop>
+						<d:status>HTTP/1.1 200 OK</d:status>
+					</d:propstat>
+				</d:response>
+			</d:multistatus>`, size, etag)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL, gowebdav.EnableStatCache(time.Minute))
+
+	fi, err := client.Stat("a.txt")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(fi.Size()).To(Equal(int64(100)))
+	g.Expect(notModified).To(Equal(0))
+
+	fi, err = client.Stat("/a.txt")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(fi.Size()).To(Equal(int64(100)))
+	g.Expect(notModified).To(Equal(1))
+
+	t.Logf("the file changes on the server\n")
+	etag, size = `"v2"`, 200
+	fi, err = client.Stat("a.txt")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(fi.Size()).To(Equal(int64(200)))
+	g.Expect(notModified).To(Equal(1))
+
+	t.Logf("after invalidation, the request is unconditional\n")
+	client.InvalidateCache("a.txt")
+	requests = 0
+	_, err = client.Stat("a.txt")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(requests).To(Equal(1))
+	g.Expect(notModified).To(Equal(1))
+
+	t.Logf("a ttl that is not positive disables the cache\n")
+	for _, ttl := range []time.Duration{0, -time.Second} {
+		client = gowebdav.NewClient(server.URL, gowebdav.EnableStatCache(ttl))
+		for i := 0; i < 2; i++ {
+			_, err = client.Stat("a.txt")
+			g.Expect(err).NotTo(HaveOccurred())
+		}
+		g.Expect(notModified).To(Equal(1))
+	}
+}
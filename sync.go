@@ -0,0 +1,273 @@
+package gowebdav
+
+import (
+	"io/fs"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Sync actions, as passed to SyncOptions.Report.
+const (
+	SyncMkdir = "mkdir"
+	SyncPut   = "put"
+	SyncGet   = "get"
+	SyncSkip  = "skip"
+)
+
+// SyncOptions controls how PutDirectory and GetDirectory copy a directory tree. The paths it
+// uses in reports and failures are slash-separated and relative to the
+// directories being synced.
+type SyncOptions struct {
+	// Concurrency is how many files are transferred at once. If zero, the value
+	// set by SetBatchConcurrency is used.
+	Concurrency int
+
+	// Include and Exclude are glob patterns, as used by path.Match. A pattern
+	// containing a slash is matched against the relative path; otherwise it is
+	// matched against the base name. A file is transferred if it matches any
+	// Include pattern, or there are none, and no Exclude pattern. Directories
+	// that match an Exclude pattern are skipped entirely.
+	Include []string
+	Exclude []string
+
+	// Incremental skips files that already exist at the destination with the
+	// same size and a modification time no older than the source file.
+	Incremental bool
+
+	// DryRun reports the planned actions without performing them.
+	DryRun bool
+
+	// Report, if not nil, is called with each planned action before any of them
+	// are performed. Otherwise, actions are written to the client's logger.
+	Report func(action, path string)
+}
+
+func (o SyncOptions) report(c *client, action, path string) {
+	if o.Report != nil {
+		o.Report(action, path)
+	} else {
+		c.logf("%s %s", action, path)
+	}
+}
+
+func (o SyncOptions) concurrency(c *client) int {
+	if o.Concurrency > 0 {
+		return o.Concurrency
+	}
+	return c.concurrency()
+}
+
+// included reports whether the relative path of a file passes the filters.
+func (o SyncOptions) included(rel string) bool {
+	return (len(o.Include) == 0 || matchAny(o.Include, rel)) && !matchAny(o.Exclude, rel)
+}
+
+func matchAny(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		name := rel
+		if !strings.Contains(p, "/") {
+			name = pathpkg.Base(rel)
+		}
+		if ok, _ := pathpkg.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// upToDate reports whether an existing destination file need not be copied again.
+// It is not if the size of either file is unknown.
+func upToDate(src, dst os.FileInfo) bool {
+	return dst != nil && !dst.IsDir() &&
+		hasSize(src) && hasSize(dst) &&
+		dst.Size() == src.Size() &&
+		!dst.ModTime().Before(src.ModTime().Truncate(time.Second))
+}
+
+// PutDirectory uploads the local directory tree at localDir to remoteDir,
+// creating remote collections as needed. Directories are created first, in
+// order; then files are uploaded concurrently. It continues after failures; if
+// any occur, the error is a *BatchError listing them.
+func (c *client) PutDirectory(localDir, remoteDir string, opts SyncOptions) error {
+	remoteDir = pathpkg.Clean(withLeadingSlash(remoteDir))
+
+	var dirs, files []string
+	var failures []PathFailure
+	remoteFiles := make(map[string]map[string]os.FileInfo)
+
+	err := filepath.WalkDir(localDir, func(p string, d fs.DirEntry, err error) error {
+		rel, _ := filepath.Rel(localDir, p)
+		rel = filepath.ToSlash(rel)
+		if err != nil {
+			if rel == "." {
+				return err
+			}
+			failures = append(failures, PathFailure{Path: rel, Err: err})
+			return nil
+		}
+
+		if d.IsDir() {
+			if rel != "." && matchAny(opts.Exclude, rel) {
+				return filepath.SkipDir
+			}
+			dirs = append(dirs, rel)
+			if opts.Incremental {
+				remoteFiles[rel] = c.listing(pathpkg.Join(remoteDir, rel))
+			}
+			return nil
+		}
+
+		if !d.Type().IsRegular() || !opts.included(rel) {
+			return nil
+		}
+
+		if opts.Incremental {
+			info, err := d.Info()
+			if err == nil && upToDate(info, remoteFiles[pathpkg.Dir(rel)][d.Name()]) {
+				opts.report(c, SyncSkip, rel)
+				return nil
+			}
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, rel := range dirs {
+		opts.report(c, SyncMkdir, rel)
+	}
+	for _, rel := range files {
+		opts.report(c, SyncPut, rel)
+	}
+
+	if opts.DryRun {
+		return batchError("PutDirectory", failures)
+	}
+
+	for _, rel := range dirs {
+		if err := c.MkdirAll(pathpkg.Join(remoteDir, rel), 0755); err != nil {
+			failures = append(failures, PathFailure{Path: rel, Err: err})
+		}
+	}
+
+	failures = append(failures, runBatch(files, opts.concurrency(c), func(rel string) error {
+		return c.PutFromFile(pathpkg.Join(remoteDir, rel), filepath.Join(localDir, filepath.FromSlash(rel)))
+	})...)
+
+	return batchError("PutDirectory", failures)
+}
+
+// GetDirectory downloads the remote directory tree at remoteDir to localDir,
+// creating local directories as needed and setting the modification time of
+// each file to match the remote one. Directories are created first, in order;
+// then files are downloaded concurrently. It continues after failures; if any
+// occur, the error is a *BatchError listing them.
+func (c *client) GetDirectory(remoteDir, localDir string, opts SyncOptions) error {
+	remoteDir = pathpkg.Clean(withLeadingSlash(remoteDir))
+
+	var dirs, files []string
+	var failures []PathFailure
+	infos := make(map[string]os.FileInfo)
+
+	err := c.Walk(remoteDir, func(p string, info os.FileInfo, err error) error {
+		rel := relativePath(remoteDir, p)
+		if err != nil {
+			if rel == "." && info == nil {
+				return err
+			}
+			failures = append(failures, PathFailure{Path: rel, Err: err})
+			return nil
+		}
+
+		if info.IsDir() {
+			if rel != "." && matchAny(opts.Exclude, rel) {
+				return filepath.SkipDir
+			}
+			dirs = append(dirs, rel)
+			return nil
+		}
+
+		if !opts.included(rel) {
+			return nil
+		}
+
+		if opts.Incremental {
+			local, err := os.Stat(filepath.Join(localDir, filepath.FromSlash(rel)))
+			if err == nil && upToDate(info, local) {
+				opts.report(c, SyncSkip, rel)
+				return nil
+			}
+		}
+		files = append(files, rel)
+		infos[rel] = info
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, rel := range dirs {
+		opts.report(c, SyncMkdir, rel)
+	}
+	for _, rel := range files {
+		opts.report(c, SyncGet, rel)
+	}
+
+	if opts.DryRun {
+		return batchError("GetDirectory", failures)
+	}
+
+	for _, rel := range dirs {
+		if err := os.MkdirAll(filepath.Join(localDir, filepath.FromSlash(rel)), 0755); err != nil {
+			failures = append(failures, PathFailure{Path: rel, Err: err})
+		}
+	}
+
+	failures = append(failures, runBatch(files, opts.concurrency(c), func(rel string) error {
+		local := filepath.Join(localDir, filepath.FromSlash(rel))
+		if err := c.GetToFile(pathpkg.Join(remoteDir, rel), local, 0644); err != nil {
+			return err
+		}
+		mtime := infos[rel].ModTime()
+		return os.Chtimes(local, mtime, mtime)
+	})...)
+
+	return batchError("GetDirectory", failures)
+}
+
+// relativePath gets the slash-separated path of p relative to the directory
+// dir, which contains it.
+func relativePath(dir, p string) string {
+	rel := strings.TrimPrefix(strings.TrimPrefix(p, dir), "/")
+	if rel == "" {
+		return "."
+	}
+	return rel
+}
+
+// hasSize reports whether the size of a file is known. It is for local files
+// and for remote files if the server reported it.
+func hasSize(fi os.FileInfo) bool {
+	if s, ok := fi.(interface{ HasSize() bool }); ok {
+		return s.HasSize()
+	}
+	return true
+}
+
+// listing gets the members of a remote collection by name. It is empty if the
+// collection cannot be read, for example because it does not exist yet.
+func (c *client) listing(path string) map[string]os.FileInfo {
+	m := make(map[string]os.FileInfo)
+	files, err := c.ReadDir(path)
+	if err == nil {
+		for _, f := range files {
+			m[f.Name()] = f
+		}
+	}
+	return m
+}
@@ -0,0 +1,169 @@
+package gowebdav_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/rickb777/gowebdav"
+	"golang.org/x/net/webdav"
+)
+
+func TestPutDirectory(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	dir := t.TempDir()
+	for _, f := range []string{"a.txt", "b.log", "sub/c.txt", "sub/deep/d.txt", "tmp/e.txt"} {
+		p := filepath.Join(dir, filepath.FromSlash(f))
+		must(t, os.MkdirAll(filepath.Dir(p), 0755))
+		must(t, ioutil.WriteFile(p, []byte(f), 0644))
+	}
+
+	var mu sync.Mutex
+	var actions []string
+	opts := gowebdav.SyncOptions{
+		Exclude: []string{"*.log", "tmp"},
+		DryRun:  true,
+		Report: func(action, path string) {
+			mu.Lock()
+			actions = append(actions, action+" "+path)
+			mu.Unlock()
+		},
+	}
+
+	// dry run does nothing
+	must(t, client.PutDirectory(dir, "backup", opts))
+	g.Expect(actions).To(Equal([]string{
+		"mkdir .", "mkdir sub", "mkdir sub/deep",
+		"put a.txt", "put sub/c.txt", "put sub/deep/d.txt",
+	}))
+	_, err := client.Stat("backup")
+	g.Expect(errors.Is(err, os.ErrNotExist)).To(BeTrue())
+
+	// real run
+	opts.DryRun = false
+	must(t, client.PutDirectory(dir, "backup", opts))
+	bs, err := client.ReadFile("backup/sub/deep/d.txt")
+	g.Expect(string(bs), err).To(Equal("sub/deep/d.txt"))
+	_, err = client.Stat("backup/b.log")
+	g.Expect(errors.Is(err, os.ErrNotExist)).To(BeTrue())
+	_, err = client.Stat("backup/tmp")
+	g.Expect(errors.Is(err, os.ErrNotExist)).To(BeTrue())
+
+	// incremental run only uploads what has changed
+	must(t, ioutil.WriteFile(filepath.Join(dir, "a.txt"), []byte("changed"), 0644))
+	actions = nil
+	opts.Incremental = true
+	must(t, client.PutDirectory(dir, "backup", opts))
+	g.Expect(actions).To(ConsistOf(
+		"skip sub/c.txt", "skip sub/deep/d.txt",
+		"mkdir .", "mkdir sub", "mkdir sub/deep",
+		"put a.txt",
+	))
+	bs, err = client.ReadFile("backup/a.txt")
+	g.Expect(string(bs), err).To(Equal("changed"))
+}
+
+func TestPutDirectory_failures(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+	must(t, client.WriteFile("backup", []byte("not a directory"), 0644))
+
+	dir := t.TempDir()
+	must(t, ioutil.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0644))
+	must(t, ioutil.WriteFile(filepath.Join(dir, "b.txt"), []byte("b"), 0644))
+
+	err := client.PutDirectory(dir, "backup", gowebdav.SyncOptions{Concurrency: 1})
+	var be *gowebdav.BatchError
+	g.Expect(errors.As(err, &be)).To(BeTrue())
+	g.Expect(be.Op).To(Equal("PutDirectory"))
+	g.Expect(len(be.Failures)).To(BeNumerically(">=", 2))
+
+	g.Expect(client.PutDirectory(filepath.Join(dir, "missing"), "x", gowebdav.SyncOptions{})).To(HaveOccurred())
+}
+
+func TestGetDirectory(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+	for _, f := range []string{"a.txt", "b.log", "sub/c.txt", "sub/deep/d.txt"} {
+		must(t, client.MkdirAll(path.Dir("/backup/"+f), 0755))
+		must(t, client.WriteFile("backup/"+f, []byte(f), 0644))
+	}
+	must(t, client.Mkdir("backup/empty", 0755))
+
+	var actions []string
+	opts := gowebdav.SyncOptions{
+		Exclude: []string{"*.log"},
+		DryRun:  true,
+		Report: func(action, path string) {
+			actions = append(actions, action+" "+path)
+		},
+	}
+
+	// dry run does nothing
+	dir := filepath.Join(t.TempDir(), "restore")
+	must(t, client.GetDirectory("backup", dir, opts))
+	g.Expect(actions).To(Equal([]string{
+		"mkdir .", "mkdir empty", "mkdir sub", "mkdir sub/deep",
+		"get a.txt", "get sub/c.txt", "get sub/deep/d.txt",
+	}))
+	_, err := os.Stat(dir)
+	g.Expect(os.IsNotExist(err)).To(BeTrue())
+
+	// real run
+	opts.DryRun = false
+	must(t, client.GetDirectory("backup", dir, opts))
+	bs, err := ioutil.ReadFile(filepath.Join(dir, "sub", "deep", "d.txt"))
+	g.Expect(string(bs), err).To(Equal("sub/deep/d.txt"))
+	g.Expect(filepath.Join(dir, "empty")).To(BeADirectory())
+	g.Expect(filepath.Join(dir, "b.log")).NotTo(BeAnExistingFile())
+
+	remote, err := client.Stat("backup/a.txt")
+	g.Expect(err).NotTo(HaveOccurred())
+	local, err := os.Stat(filepath.Join(dir, "a.txt"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(local.ModTime().Equal(remote.ModTime())).To(BeTrue())
+
+	// incremental run only downloads what has changed
+	must(t, client.WriteFile("backup/a.txt", []byte("changed"), 0644))
+	actions = nil
+	opts.Incremental = true
+	must(t, client.GetDirectory("backup", dir, opts))
+	g.Expect(actions).To(Equal([]string{
+		"skip sub/c.txt", "skip sub/deep/d.txt",
+		"mkdir .", "mkdir empty", "mkdir sub", "mkdir sub/deep",
+		"get a.txt",
+	}))
+	bs, err = ioutil.ReadFile(filepath.Join(dir, "a.txt"))
+	g.Expect(string(bs), err).To(Equal("changed"))
+
+	g.Expect(client.GetDirectory("missing", dir, opts)).To(HaveOccurred())
+}
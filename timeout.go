@@ -0,0 +1,56 @@
+package gowebdav
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// SetTimeout limits the time taken by each request, without needing a custom
+// http.Client. This is a whole-operation timeout, like http.Client.Timeout: it
+// covers connecting, any redirects and reading the response body. So for large
+// downloads via ReadStream, it limits the whole transfer, which may not be what
+// you want; in that case, consider SetResponseHeaderTimeout instead.
+//
+// The timeout is set on a copy of the *http.Client, including one supplied via
+// SetHttpClient. It is ignored for other HttpClients.
+func SetTimeout(d time.Duration) ClientOpt {
+	return func(c Client) {
+		c.(*client).timeout = d
+	}
+}
+
+// SetDialTimeout limits the time taken to establish each connection to the server.
+// Unlike SetTimeout, it does not limit the time taken by the request once
+// connected. See SetClientCertificate for when this applies.
+func SetDialTimeout(d time.Duration) ClientOpt {
+	return func(c Client) {
+		c.(*client).transportOpts = append(c.(*client).transportOpts, func(t *http.Transport) {
+			t.DialContext = (&net.Dialer{Timeout: d, KeepAlive: 30 * time.Second}).DialContext
+		})
+	}
+}
+
+// SetResponseHeaderTimeout limits the time spent waiting for the server's response
+// headers after the request has been sent. Unlike SetTimeout, it does not limit the
+// time taken to read the response body, so it suits large downloads. See
+// SetClientCertificate for when this applies.
+func SetResponseHeaderTimeout(d time.Duration) ClientOpt {
+	return func(c Client) {
+		c.(*client).transportOpts = append(c.(*client).transportOpts, func(t *http.Transport) {
+			t.ResponseHeaderTimeout = d
+		})
+	}
+}
+
+// withTimeout returns a copy of hc with the given timeout.
+func withTimeout(hc HttpClient, d time.Duration) HttpClient {
+	hcc, ok := hc.(*http.Client)
+	if !ok {
+		return hc
+	}
+
+	cp := *hcc
+	cp.Timeout = d
+	return &cp
+}
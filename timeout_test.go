@@ -0,0 +1,55 @@
+package gowebdav_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/rickb777/gowebdav"
+)
+
+func slowServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow.txt" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		fmt.Fprint(w, "hello")
+	}))
+}
+
+func TestSetTimeout(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := slowServer()
+	defer server.Close()
+
+	hc := &http.Client{}
+	client := gowebdav.NewClient(server.URL, gowebdav.SetHttpClient(hc), gowebdav.SetTimeout(50*time.Millisecond))
+
+	bs, err := client.ReadFile("fast.txt")
+	g.Expect(string(bs), err).To(Equal("hello"))
+
+	_, err = client.ReadFile("slow.txt")
+	g.Expect(err).To(MatchError(ContainSubstring("Client.Timeout exceeded")))
+	g.Expect(hc.Timeout).To(BeZero())
+}
+
+func TestSetResponseHeaderTimeout(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := slowServer()
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL,
+		gowebdav.SetResponseHeaderTimeout(50*time.Millisecond),
+		gowebdav.SetDialTimeout(time.Second))
+
+	bs, err := client.ReadFile("fast.txt")
+	g.Expect(string(bs), err).To(Equal("hello"))
+
+	_, err = client.ReadFile("slow.txt")
+	g.Expect(err).To(MatchError(ContainSubstring("timeout awaiting response headers")))
+}
@@ -0,0 +1,79 @@
+package gowebdav
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// GetToFile downloads a remote file to a local file, streaming it so that large
+// files are not held in memory. Any missing local parent directories are created.
+// If the download fails, the partial local file is removed.
+func (c *client) GetToFile(remotePath, localPath string, perm os.FileMode) error {
+	stream, err := c.ReadStream(remotePath)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	if err = os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(localPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(f, stream)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		_ = os.Remove(localPath)
+		return newPathErrorErr("GetToFile", remotePath, err)
+	}
+	return nil
+}
+
+// PutFromFile uploads a local file to a remote file, streaming it so that large
+// files are not held in memory. The request has a Content-Length header.
+func (c *client) PutFromFile(remotePath, localPath string, opts ...OpOpt) error {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if fi.IsDir() {
+		return newPathErrorErr("PutFromFile", localPath, errors.New("is a directory"))
+	}
+
+	return c.WriteStreamSized(remotePath, f, fi.Size(), "", opts...)
+}
+
+// CopyBetween copies the file at srcPath on src to dstPath on dst, which may be a
+// different server, creating parent collections on the destination as needed.
+// The content is streamed from one to the other, not held in memory, unless dst
+// uses auth.Deferred and has not yet been challenged, in which case the upload is
+// buffered so that it can be re-sent. When src gives the length and content type,
+// they are sent with the upload. Cancelling ctx stops the transfer.
+func CopyBetween(ctx context.Context, src Client, srcPath string, dst Client, dstPath string) error {
+	stream, res, err := src.ReadStreamWithResponse(srcPath, WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	if res.ContentLength >= 0 {
+		return dst.WriteStreamSized(dstPath, stream, res.ContentLength, res.Header.Get("Content-Type"), WithContext(ctx))
+	}
+	return dst.WriteStream(dstPath, stream, 0644, WithContext(ctx))
+}
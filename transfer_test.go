@@ -0,0 +1,76 @@
+package gowebdav_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/rickb777/gowebdav"
+	"golang.org/x/net/webdav"
+)
+
+func TestGetToFile_PutFromFile(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+	dir := t.TempDir()
+
+	local := filepath.Join(dir, "upload.txt")
+	must(t, ioutil.WriteFile(local, []byte("Hello World"), 0644))
+
+	must(t, client.PutFromFile("docs/hello.txt", local))
+	bs, err := client.ReadFile("docs/hello.txt")
+	g.Expect(string(bs), err).To(Equal("Hello World"))
+
+	download := filepath.Join(dir, "a", "b", "download.txt")
+	must(t, client.GetToFile("docs/hello.txt", download, 0600))
+	bs, err = ioutil.ReadFile(download)
+	g.Expect(string(bs), err).To(Equal("Hello World"))
+
+	g.Expect(client.PutFromFile("docs/dir", dir)).To(MatchError(ContainSubstring("is a directory")))
+	g.Expect(client.GetToFile("docs/missing.txt", filepath.Join(dir, "missing.txt"), 0600)).To(HaveOccurred())
+}
+
+func TestCopyBetween(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	newServer := func() *httptest.Server {
+		return httptest.NewServer(&webdav.Handler{
+			FileSystem: webdav.NewMemFS(),
+			LockSystem: webdav.NewMemLS(),
+		})
+	}
+
+	srcServer := newServer()
+	defer srcServer.Close()
+	dstServer := newServer()
+	defer dstServer.Close()
+
+	src := gowebdav.NewClient(srcServer.URL)
+	dst := gowebdav.NewClient(dstServer.URL)
+	must(t, src.Mkdir("docs", 0755))
+	must(t, src.WriteFile("docs/hello.txt", []byte("Hello World"), 0644))
+
+	must(t, gowebdav.CopyBetween(context.Background(), src, "docs/hello.txt", dst, "archive/2021/hello.txt"))
+	bs, err := dst.ReadFile("archive/2021/hello.txt")
+	g.Expect(string(bs), err).To(Equal("Hello World"))
+
+	err = gowebdav.CopyBetween(context.Background(), src, "docs/missing.txt", dst, "archive/missing.txt")
+	g.Expect(errors.Is(err, os.ErrNotExist)).To(BeTrue())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = gowebdav.CopyBetween(ctx, src, "docs/hello.txt", dst, "archive/cancelled.txt")
+	g.Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+}
@@ -5,19 +5,47 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io"
+	"mime"
+	"net/http"
 	"net/url"
 	"os"
 	"strconv"
 	"strings"
 	"time"
+
+	"golang.org/x/net/html/charset"
 )
 
-func log(msg interface{}) {
-	fmt.Println(msg)
+// logf formats a message for the client's logger.
+func (c *client) logf(format string, args ...interface{}) {
+	c.logger(fmt.Sprintf(format, args...))
 }
 
 func newPathError(op string, path string, statusCode int) error {
-	return newPathErrorErr(op, path, fmt.Errorf("%d", statusCode))
+	return &StatusError{
+		Op:         op,
+		Path:       path,
+		StatusCode: statusCode,
+		Status:     fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+	}
+}
+
+// withOp wraps err in a *os.PathError unless it already is one or is a *StatusError.
+func withOp(op string, path string, err error) error {
+	switch err.(type) {
+	case *os.PathError, *StatusError:
+		return err
+	}
+	return newPathErrorErr(op, path, err)
+}
+
+// withPathError wraps err in a *os.PathError unless it already is one, for the
+// methods that promise a *os.PathError as the os package does.
+func withPathError(op string, path string, err error) error {
+	if _, ok := err.(*os.PathError); ok {
+		return err
+	}
+	return newPathErrorErr(op, path, err)
 }
 
 func newPathErrorErr(op string, path string, err error) error {
@@ -28,13 +56,38 @@ func newPathErrorErr(op string, path string, err error) error {
 	}
 }
 
-// pathEscape escapes all segments of a given path
+// pathEscape escapes all segments of a given path. The path is always treated as
+// the literal name of a resource, so "a%20b" is escaped to "a%2520b", not left as
+// a space. Characters such as '+', ':', '&' and '=' are allowed in a URL path and
+// are left alone; ' ', '#', '?' and '%' are escaped.
 func pathEscape(path string) string {
-	s := strings.Split(path, "/")
-	for i, e := range s {
-		s[i] = url.PathEscape(e)
+	return (&url.URL{Path: path}).EscapedPath()
+}
+
+// normaliseRoot checks the root URL given to NewClient, adding the https scheme if
+// there is none and removing any trailing slash.
+func normaliseRoot(uri string) (string, error) {
+	uri = strings.TrimSpace(uri)
+	if !strings.Contains(uri, "://") {
+		uri = "https://" + uri
 	}
-	return strings.Join(s, "/")
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("invalid root URL: %w", err)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	switch {
+	case u.Scheme != "http" && u.Scheme != "https":
+		return "", fmt.Errorf("invalid root URL %q: the scheme must be http or https", uri)
+	case u.Host == "":
+		return "", fmt.Errorf("invalid root URL %q: there is no host", uri)
+	case u.RawQuery != "" || u.ForceQuery || u.Fragment != "":
+		return "", fmt.Errorf("invalid root URL %q: it must not have a query or fragment", uri)
+	}
+
+	return withoutTrailingSlash(u.String()), nil
 }
 
 // withoutTrailingSlash removes any trailing / from a string
@@ -69,12 +122,45 @@ func withSurroundingSlashes(s string) string {
 	return withTrailingSlash(s)
 }
 
+// quoteETag adds the double quotes required around an entity tag, unless it is
+// already quoted or is a weak tag.
+func quoteETag(etag string) string {
+	if strings.HasPrefix(etag, `"`) || strings.HasPrefix(etag, `W/"`) {
+		return etag
+	}
+	return `"` + etag + `"`
+}
+
+// splitHeaderList splits the comma-separated values of a header, which may occur
+// more than once, discarding blanks.
+func splitHeaderList(values []string) []string {
+	var list []string
+	for _, v := range values {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				list = append(list, s)
+			}
+		}
+	}
+	return list
+}
+
+// withoutString returns a copy of list without any occurrences of s
+func withoutString(list []string, s string) []string {
+	var result []string
+	for _, v := range list {
+		if v != s {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
 // readString pulls a string out of our io.Reader
-func readString(r io.Reader) string {
+func readString(r io.Reader) (string, error) {
 	buf := new(bytes.Buffer)
-	// TODO - make String return an error as well
-	_, _ = buf.ReadFrom(r)
-	return buf.String()
+	_, err := buf.ReadFrom(r)
+	return buf.String(), err
 }
 
 func parseUint(s *string) uint {
@@ -84,33 +170,102 @@ func parseUint(s *string) uint {
 	return 0
 }
 
-func parseInt64(s *string) int64 {
-	if n, e := strconv.ParseInt(*s, 10, 64); e == nil {
+// parseSize parses a getcontentlength value, reporting whether there was one.
+func parseSize(s *string) (int64, bool) {
+	if n, e := strconv.ParseInt(strings.TrimSpace(*s), 10, 64); e == nil {
+		return n, true
+	}
+	return 0, false
+}
+
+// parseStatusCode extracts the code from a status line such as "HTTP/1.1 423 Locked",
+// returning 0 if there is none.
+func parseStatusCode(s string) int {
+	fields := strings.Fields(s)
+	if len(fields) < 2 {
+		return 0
+	}
+	if n, e := strconv.Atoi(fields[1]); e == nil {
 		return n
 	}
 	return 0
 }
 
+// timeLayouts are the timestamp formats servers are known to use, most common first.
+var timeLayouts = []string{
+	time.RFC1123,
+	time.RFC1123Z,
+	time.RFC3339,
+	"2006-01-02T15:04:05Z",
+}
+
+func parseTime(s string) (time.Time, bool) {
+	for _, layout := range timeLayouts {
+		if t, e := time.Parse(layout, s); e == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
 func parseModified(s *string) time.Time {
-	if t, e := time.Parse(time.RFC1123, *s); e == nil {
+	if t, ok := parseTime(*s); ok {
 		return t
 	}
 	return time.Unix(0, 0)
 }
 
-func parseXML(data io.Reader, resp interface{}, parse func(resp interface{}) error) error {
+func parseCreated(s *string) time.Time {
+	t, _ := parseTime(*s)
+	return t
+}
+
+// xmlDecoder makes a decoder for an XML response body. A charset given in the
+// Content-Type header takes precedence over the one in the XML declaration, as
+// RFC 7303 requires; either may name any encoding known to x/net/html/charset.
+func xmlDecoder(data io.Reader, contentType string) (*xml.Decoder, error) {
+	if _, params, err := mime.ParseMediaType(contentType); err == nil && params["charset"] != "" {
+		r, err := charset.NewReaderLabel(params["charset"], data)
+		if err != nil {
+			return nil, err
+		}
+		decoder := xml.NewDecoder(r)
+		// the body is already UTF-8, whatever the XML declaration says
+		decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+			return input, nil
+		}
+		return decoder, nil
+	}
+
 	decoder := xml.NewDecoder(data)
-	for t, _ := decoder.Token(); t != nil; t, _ = decoder.Token() {
-		switch se := t.(type) {
-		case xml.StartElement:
-			if se.Name.Local == "response" {
-				if e := decoder.DecodeElement(resp, &se); e == nil {
-					if err := parse(resp); err != nil {
-						return err
-					}
-				}
+	decoder.CharsetReader = charset.NewReaderLabel
+	return decoder, nil
+}
+
+// parseXML decodes each response element of a multistatus body into resp and
+// passes it to parse. A body that is not well-formed, such as one that has been
+// truncated, gives an error, rather than just fewer responses.
+func parseXML(data io.Reader, contentType string, resp interface{}, parse func(resp interface{}) error) error {
+	decoder, err := xmlDecoder(data, contentType)
+	if err != nil {
+		return err
+	}
+	for {
+		t, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid multistatus response: %w", err)
+		}
+
+		if se, ok := t.(xml.StartElement); ok && se.Name.Local == "response" {
+			if err = decoder.DecodeElement(resp, &se); err != nil {
+				return fmt.Errorf("invalid multistatus response: %w", err)
+			}
+			if err = parse(resp); err != nil {
+				return err
 			}
 		}
 	}
-	return nil
 }
@@ -1,10 +1,12 @@
 package gowebdav
 
 import (
+	"encoding/xml"
 	"fmt"
 	"net/url"
 	"path"
 	"testing"
+	"time"
 )
 
 func TestJoin(t *testing.T) {
@@ -45,6 +47,33 @@ func TestEscapeURL(t *testing.T) {
 	}
 }
 
+func TestPathEscape(t *testing.T) {
+	cases := map[string]string{
+		"/a b.txt":   "/a%20b.txt",
+		"/a#b.txt":   "/a%23b.txt",
+		"/a?b.txt":   "/a%3Fb.txt",
+		"/a+b.txt":   "/a+b.txt",
+		"/a:b.txt":   "/a:b.txt",
+		"/a%20b.txt": "/a%2520b.txt",
+		"/é/ü.txt":   "/%C3%A9/%C3%BC.txt",
+	}
+
+	for input, expected := range cases {
+		got := pathEscape(input)
+		if got != expected {
+			t.Errorf("expected: %q got %q", expected, got)
+		}
+	}
+}
+
+func TestResourceURL(t *testing.T) {
+	c := &client{root: "https://foo.com/dav/user%40example"}
+	ex := "https://foo.com/dav/user%40example/a%20b/c+d%3F.txt"
+	if got := c.resourceURL("/a b/c+d?.txt"); got != ex {
+		t.Error("expected: " + ex + " got: " + got)
+	}
+}
+
 func TestWithTrailingSlash(t *testing.T) {
 	cases := map[string]string{
 		"":       "/",
@@ -77,6 +106,30 @@ func TestWithoutTrailingSlash(t *testing.T) {
 	}
 }
 
+func TestNormaliseRoot(t *testing.T) {
+	cases := map[string]string{
+		"example.com/dav":                     "https://example.com/dav",
+		"example.com:8443/dav/":               "https://example.com:8443/dav",
+		"http://example.com/":                 "http://example.com",
+		"HTTPS://example.com/remote.php/dav/": "https://example.com/remote.php/dav",
+		"https://example.com/dav/user%40x":    "https://example.com/dav/user%40x",
+		" https://example.com/a%20b/c ":       "https://example.com/a%20b/c",
+	}
+
+	for input, expected := range cases {
+		got, err := normaliseRoot(input)
+		if err != nil || got != expected {
+			t.Errorf("%q: expected: %q got %q, %v", input, expected, got, err)
+		}
+	}
+
+	for _, input := range []string{"", "ftp://example.com/dav", "https:///dav", "https://example.com/dav?x=1", "https://example.com/dav#top", "https://exa mple.com"} {
+		if got, err := normaliseRoot(input); err == nil {
+			t.Errorf("%q: expected an error, got %q", input, got)
+		}
+	}
+}
+
 func TestWithSurroundingSlashes(t *testing.T) {
 	cases := map[string]string{
 		"":       "/",
@@ -94,3 +147,67 @@ func TestWithSurroundingSlashes(t *testing.T) {
 		}
 	}
 }
+
+func TestParseModified(t *testing.T) {
+	expected := time.Date(2021, 4, 16, 10, 20, 30, 0, time.UTC)
+	cases := []string{
+		"Fri, 16 Apr 2021 10:20:30 UTC",
+		"Fri, 16 Apr 2021 10:20:30 +0000",
+		"2021-04-16T10:20:30Z",
+		"2021-04-16T11:20:30+01:00",
+	}
+
+	for _, input := range cases {
+		got := parseModified(&input)
+		if !got.Equal(expected) {
+			t.Errorf("%q: expected: %v got %v", input, expected, got)
+		}
+	}
+
+	bad := "yesterday"
+	if got := parseModified(&bad); !got.Equal(time.Unix(0, 0)) {
+		t.Errorf("expected the epoch, got %v", got)
+	}
+	if got := parseCreated(&bad); !got.IsZero() {
+		t.Errorf("expected the zero time, got %v", got)
+	}
+}
+
+func TestHrefUnder(t *testing.T) {
+	cases := []struct {
+		dir, href, rel string
+		ok             bool
+	}{
+		{"/a/dir/", "/a/dir/x%20y.txt", "x y.txt", true},
+		{"/a/dir", "/a/dir/sub/", "sub", true},
+		{"/a/dir/", "http://backend:8080/a/dir/b.txt", "b.txt", true},
+		{"/a/dir/", "/a/dir/", "", false},
+		{"/a/dir/", "/a/directory/b.txt", "", false},
+	}
+	for _, c := range cases {
+		rel, ok := hrefUnder(c.dir, c.href)
+		if rel != c.rel || ok != c.ok {
+			t.Errorf("hrefUnder(%q, %q): got %q %v, want %q %v", c.dir, c.href, rel, ok, c.rel, c.ok)
+		}
+	}
+}
+
+func TestPropfindBody(t *testing.T) {
+	cases := []struct {
+		prefix string
+		props  []xml.Name
+		body   string
+	}{
+		{"d", nil, `<d:propfind xmlns:d="DAV:"><d:allprop/></d:propfind>`},
+		{"D", []xml.Name{{Space: "DAV:", Local: "getetag"}}, `<D:propfind xmlns:D="DAV:"><D:prop><D:getetag/></D:prop></D:propfind>`},
+		{"", []xml.Name{{Space: "DAV:", Local: "getetag"}, {Local: "x"}}, `<propfind xmlns="DAV:"><prop><getetag/><x xmlns=""/></prop></propfind>`},
+		{"ns1", []xml.Name{{Space: "urn:a", Local: "x"}, {Space: "urn:b", Local: "y"}, {Space: "urn:a", Local: "z"}},
+			`<ns1:propfind xmlns:ns1="DAV:" xmlns:ns2="urn:a" xmlns:ns3="urn:b"><ns1:prop><ns2:x/><ns3:y/><ns2:z/></ns1:prop></ns1:propfind>`},
+	}
+	for _, c := range cases {
+		body := propfindBody(c.prefix, c.props)
+		if body != c.body {
+			t.Errorf("propfindBody(%q, %v): got %s, want %s", c.prefix, c.props, body, c.body)
+		}
+	}
+}
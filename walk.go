@@ -0,0 +1,135 @@
+package gowebdav
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"sort"
+)
+
+// Walk walks the remote file tree rooted at root, calling fn for each file or
+// collection in the tree, including root. It follows the same rules as filepath.Walk:
+// entries are visited in lexical order; if fn returns filepath.SkipDir for a
+// collection, its members are skipped; and an error listing a collection is passed
+// to fn, which decides whether to continue. The paths passed to fn are rooted,
+// slash-separated and have no trailing slash.
+//
+// Each collection is listed with a single Depth:1 PROPFIND. A collection that the
+// server reports with the same href as one already visited is a symlink-like loop
+// on the server, so it is not descended into again; fn is passed an error wrapping
+// ErrWalkLoop for it instead. Hrefs are compared after unescaping and cleaning. As
+// a last resort, for loops that the server reports under ever longer hrefs,
+// collections nested more than maxWalkDepth deep are not listed either; fn is
+// passed an error wrapping ErrTooDeep for them.
+func (c *client) Walk(root string, fn filepath.WalkFunc) error {
+	root = pathpkg.Clean(withLeadingSlash(root))
+
+	info, err := c.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = c.walk(root, info, fn, 0, make(map[string]struct{}))
+	}
+
+	if err == filepath.SkipDir {
+		return nil
+	}
+	return err
+}
+
+// maxWalkDepth limits how deeply Walk descends, in case there is a loop that
+// cannot be recognised from the hrefs.
+const maxWalkDepth = 256
+
+func (c *client) walk(path string, info os.FileInfo, fn filepath.WalkFunc, depth int, visited map[string]struct{}) error {
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+
+	if err := fn(path, info, nil); err != nil {
+		return err
+	}
+
+	if depth >= maxWalkDepth {
+		return fn(path, info, newPathErrorErr("Walk", path, ErrTooDeep))
+	}
+
+	href, files, err := c.readDir(path)
+	if err != nil {
+		return fn(path, info, err)
+	}
+
+	key := pathpkg.Clean(c.hrefPath(href))
+	if _, seen := visited[key]; seen {
+		return fn(path, info, newPathErrorErr("Walk", path, ErrWalkLoop))
+	}
+	visited[key] = struct{}{}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name() < files[j].Name()
+	})
+
+	for _, f := range files {
+		err = c.walk(pathpkg.Join(path, f.Name()), f, fn, depth+1, visited)
+		if err != nil {
+			if !f.IsDir() || err != filepath.SkipDir {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ReadDirInfinite lists all the files and collections below the remote directory at
+// path, however deeply nested, using a single PROPFIND with Depth: infinity. This
+// is much faster than Walk for large trees, but many servers do not allow it and
+// respond 403 Forbidden (or 507 Insufficient Storage if the result would be too
+// large); the error then wraps ErrDepthInfinityNotSupported.
+//
+// The entries are returned in the order the server sent them. Their paths are
+// rooted and slash-separated; collections have a trailing slash, as with ReadDir.
+func (c *client) ReadDirInfinite(path string) ([]os.FileInfo, error) {
+	path = withSurroundingSlashes(path)
+	files := make([]os.FileInfo, 0)
+
+	parse := func(resp interface{}) error {
+		r := resp.(*response)
+		defer func() { r.Props = nil }()
+
+		p := getProps(r, responseStatusOK)
+		rel := withSurroundingSlashes(c.hrefPath(r.Href))
+		if rel == path {
+			if p == nil || p.Type.Local != "collection" {
+				return newPathErrorErr("ReadDirInfinite", path, ErrNotDirectory)
+			}
+			return nil
+		}
+
+		if p == nil {
+			return nil
+		}
+
+		fi := newFileinfo(p)
+		fi.path = withoutTrailingSlash(rel)
+		fi.name = pathpkg.Base(fi.path)
+		if p.Type.Local == "collection" {
+			fi.path += "/"
+			fi.isdir = true
+		} else {
+			fi.size, fi.hasSize = parseSize(&p.Size)
+		}
+		files = append(files, fi)
+		return nil
+	}
+
+	err := c.propfind("ReadDirInfinite", path, DepthInfinity, c.fileProperties(), &response{}, parse)
+	if err != nil {
+		if hasStatus(err, http.StatusForbidden) || hasStatus(err, http.StatusInsufficientStorage) {
+			return nil, newPathErrorErr("ReadDirInfinite", path, fmt.Errorf("%w (%v)", ErrDepthInfinityNotSupported, err))
+		}
+		return nil, withOp("ReadDirInfinite", path, err)
+	}
+	return files, nil
+}
@@ -0,0 +1,192 @@
+package gowebdav_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/rickb777/gowebdav"
+	"golang.org/x/net/webdav"
+)
+
+func TestWalk(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	for _, f := range []string{"top/b/2.txt", "top/b/1.txt", "top/a.txt", "top/skip/x.txt", "top/c/d/3.txt"} {
+		must(t, client.MkdirAll(path.Dir(f), 0755))
+		must(t, client.WriteFile(f, []byte("x"), 0644))
+	}
+
+	var visited []string
+	err := client.Walk("top", func(p string, info os.FileInfo, err error) error {
+		g.Expect(err).NotTo(HaveOccurred())
+		visited = append(visited, p)
+		if info.IsDir() && info.Name() == "skip" {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(visited).To(Equal([]string{
+		"/top",
+		"/top/a.txt",
+		"/top/b",
+		"/top/b/1.txt",
+		"/top/b/2.txt",
+		"/top/c",
+		"/top/c/d",
+		"/top/c/d/3.txt",
+		"/top/skip",
+	}))
+
+	var walkErr error
+	err = client.Walk("missing", func(p string, info os.FileInfo, err error) error {
+		walkErr = err
+		return err
+	})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(errors.Is(walkErr, os.ErrNotExist)).To(BeTrue())
+}
+
+func TestReadDirInfinite(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	for _, f := range []string{"top/b/2.txt", "top/a.txt", "top/c/d/3 x.txt"} {
+		must(t, client.MkdirAll(path.Dir(f), 0755))
+		must(t, client.WriteFile(f, []byte("x"), 0644))
+	}
+
+	files, err := client.ReadDirInfinite("top")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.(interface{ Path() string }).Path()+" "+f.Name())
+	}
+	sort.Strings(paths)
+	g.Expect(paths).To(Equal([]string{
+		"/top/a.txt a.txt",
+		"/top/b/ b",
+		"/top/b/2.txt 2.txt",
+		"/top/c/ c",
+		"/top/c/d/ d",
+		"/top/c/d/3 x.txt 3 x.txt",
+	}))
+
+	_, err = client.ReadDirInfinite("top/a.txt")
+	g.Expect(errors.Is(err, gowebdav.ErrNotDirectory)).To(BeTrue())
+}
+
+func TestReadDirInfinite_forbidden(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.Header.Get("Depth")).To(Equal("infinity"))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	_, err := client.ReadDirInfinite("top")
+	g.Expect(errors.Is(err, gowebdav.ErrDepthInfinityNotSupported)).To(BeTrue())
+	g.Expect(err).To(MatchError(ContainSubstring("use Walk instead")))
+}
+
+// loopServer serves /a/ as a collection holding f.txt and link/, where link/ is an
+// alias of /a/ itself, so that /a/link/link/... never ends. If canonical is true,
+// the aliases are listed with the href of /a/, escaped differently; otherwise
+// with their own href.
+func loopServer(canonical bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/a") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		self := strings.TrimSuffix(r.URL.Path, "/") + "/"
+		if canonical && self != "/a/" {
+			self = "/%61/"
+		}
+		dir := `<d:response><d:href>%s</d:href><d:propstat><d:prop><d:resourcetype><d:collection/></d:resourcetype>` +
+			`</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`
+
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprint(w, `<?xml version="1.0"?><d:multistatus xmlns:d="DAV:">`)
+		fmt.Fprintf(w, dir, self)
+		if r.Header.Get("Depth") == "1" {
+			fmt.Fprintf(w, `<d:response><d:href>%sf.txt</d:href><d:propstat><d:prop><d:resourcetype/>`+
+				`<d:getcontentlength>1</d:getcontentlength></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`, self)
+			fmt.Fprintf(w, dir, self+"link/")
+		}
+		fmt.Fprint(w, `</d:multistatus>`)
+	}))
+}
+
+func TestWalk_loop(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := loopServer(true)
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	var visited []string
+	var loopErr error
+	err := client.Walk("a", func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			loopErr = err
+		} else {
+			visited = append(visited, p)
+		}
+		return nil
+	})
+
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(visited).To(Equal([]string{"/a", "/a/f.txt", "/a/link"}))
+	g.Expect(errors.Is(loopErr, gowebdav.ErrWalkLoop)).To(BeTrue())
+	g.Expect(loopErr).To(MatchError("Walk /a/link: collection already visited; there is a loop"))
+}
+
+func TestWalk_loopWithoutCanonicalHrefs(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := loopServer(false)
+	defer server.Close()
+
+	client := gowebdav.NewClient(server.URL)
+
+	count := 0
+	err := client.Walk("a", func(p string, info os.FileInfo, err error) error {
+		count++
+		return err
+	})
+
+	g.Expect(errors.Is(err, gowebdav.ErrTooDeep)).To(BeTrue())
+	g.Expect(count).To(BeNumerically(">", 256))
+}